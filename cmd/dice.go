@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/spf13/cobra"
+)
+
+var diceGlobals struct {
+	Game string // name of the game file
+	Turn int    // game-turn to replay, zero for every turn
+}
+
+var diceCmd = &cobra.Command{
+	Use:   "dice",
+	Short: "dice commands",
+	Long:  `Commands to audit the dice rolled in a game.`,
+}
+
+var diceReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "derive a game-turn's rolls again from the seed",
+	Long: `Derive every recorded die roll again from the game's seed and
+report any roll that does not match the record, any roll missing from
+the sequence, and a seed that does not match its published hash.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(diceGlobals.Game)
+		cobra.CheckErr(err)
+		if g.Seed == "" {
+			cobra.CheckErr(fmt.Errorf("%s: game has no seed", diceGlobals.Game))
+		} else if g.SeedHash != "" && dice.Hash(g.Seed) != g.SeedHash {
+			cobra.CheckErr(fmt.Errorf("%s: seed does not match the published hash", diceGlobals.Game))
+		}
+
+		var rolls []*model.ROLL
+		for _, roll := range g.Rolls {
+			if diceGlobals.Turn == 0 || roll.Turn == diceGlobals.Turn {
+				rolls = append(rolls, roll)
+			}
+		}
+
+		bad := 0
+		for _, roll := range rolls {
+			if err := dice.Verify(g.Seed, roll); err != nil {
+				bad++
+				fmt.Printf("mismatch: %v\n", err)
+			} else if verboseFlag {
+				fmt.Printf("ok: %s\n", roll)
+			}
+		}
+		// rolls are numbered within a game-turn, so the turn's rolls can be checked alone
+		gaps := dice.Gaps(rolls)
+		for _, err := range gaps {
+			fmt.Printf("out of sequence: %v\n", err)
+		}
+		fmt.Printf("%d rolls replayed, %d mismatched, %d out of sequence\n", len(rolls), bad, len(gaps))
+		if bad != 0 || len(gaps) != 0 {
+			cobra.CheckErr(fmt.Errorf("%d rolls do not match the seed, %d are out of sequence", bad, len(gaps)))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diceCmd)
+	diceCmd.AddCommand(diceReplayCmd)
+	diceReplayCmd.Flags().StringVar(&diceGlobals.Game, "game", "game.json", "name of the game file")
+	diceReplayCmd.Flags().IntVar(&diceGlobals.Turn, "turn", 0, "game-turn to replay (default is every game-turn)")
+}
@@ -0,0 +1,222 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/route"
+	"github.com/mdhender/tcfna/internal/scenario"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/mdhender/tcfna/internal/store/memory"
+	"github.com/mdhender/tcfna/internal/visibility"
+	"github.com/mdhender/tcfna/internal/zoc"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"log"
+)
+
+var gameGlobals struct {
+	New struct {
+		Scenario string // name of the scenario file
+		Output   string // name of the game file to create
+		Seed     string // leave blank for a random seed
+	}
+	Path struct {
+		Game  string // name of the game file
+		Unit  string // id of the unit to move
+		To    string // label of the destination hex
+		Costs string // leave blank to use the built-in cost table
+	}
+	ZOC struct {
+		Game   string // name of the game file
+		Side   string // side exerting the zone of control
+		Output string // name of the SVG file to write, may be blank
+	}
+	Map struct {
+		Game   string // name of the game file
+		Side   string // side the map is drawn for
+		Format string // png or svg
+		Output string // name of the file to write
+	}
+}
+
+var gameCmd = &cobra.Command{
+	Use:   "game",
+	Short: "game commands",
+	Long:  `Commands to create and manage games.`,
+}
+
+var gameNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "create a new game from a scenario",
+	Long: `Create a new game by loading the scenario's board and counters,
+placing units in their starting hexes, and writing the game file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if gameGlobals.New.Scenario == "" {
+			cobra.CheckErr(fmt.Errorf("missing scenario file name"))
+		} else if gameGlobals.New.Output == "" {
+			cobra.CheckErr(fmt.Errorf("missing output file name"))
+		}
+
+		s, err := scenario.Load(gameGlobals.New.Scenario)
+		cobra.CheckErr(err)
+		board, err := jsondb.Convert(s.Board)
+		cobra.CheckErr(err)
+		units, err := jsondb.LoadCounters(s.Counters)
+		cobra.CheckErr(err)
+
+		g, err := s.NewGame(board, units)
+		cobra.CheckErr(err)
+		if g.Seed = gameGlobals.New.Seed; g.Seed == "" {
+			g.Seed, err = dice.NewSeed()
+			cobra.CheckErr(err)
+		}
+		g.SeedHash = dice.Hash(g.Seed)
+
+		cobra.CheckErr(jsondb.WriteGame(gameGlobals.New.Output, g))
+		log.Printf("[game] new: wrote %q\n", gameGlobals.New.Output)
+		log.Printf("[game] new: seed hash %s\n", g.SeedHash)
+	},
+}
+
+var gameMapCmd = &cobra.Command{
+	Use:   "map",
+	Short: "draw the board as one side sees it",
+	Long: `Draw the board with the side's units and the enemy units that the
+side can see. Hidden enemy units are not drawn.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if gameGlobals.Map.Output == "" {
+			cobra.CheckErr(fmt.Errorf("missing output file name"))
+		}
+		side, err := model.ParseSide(gameGlobals.Map.Side)
+		cobra.CheckErr(err)
+		if side == model.NoSide {
+			cobra.CheckErr(fmt.Errorf("missing side"))
+		}
+
+		g, err := jsondb.LoadGame(gameGlobals.Map.Game)
+		cobra.CheckErr(err)
+		view := visibility.Compute(g, visibility.Default, side).Filter(g)
+		ds := memory.NewView(g.Map, view.Units)
+
+		switch gameGlobals.Map.Format {
+		case "png":
+			cobra.CheckErr(ds.SaveBoardImage(gameGlobals.Map.Output))
+		case "svg":
+			cobra.CheckErr(ioutil.WriteFile(gameGlobals.Map.Output, []byte(ds.BoardAsSVG().String()), 0644))
+		default:
+			cobra.CheckErr(fmt.Errorf("unsupported map format %q", gameGlobals.Map.Format))
+		}
+		log.Printf("[game] map: wrote %q\n", gameGlobals.Map.Output)
+	},
+}
+
+var gamePathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "find the cheapest path for a unit",
+	Long: `Find and print the cheapest path for a unit to a hex, including
+the extra cost of moving into and out of enemy zones of control.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(gameGlobals.Path.Game)
+		cobra.CheckErr(err)
+		engine, err := loadMovement(g.Map, gameGlobals.Path.Costs)
+		cobra.CheckErr(err)
+
+		u := g.Units.ById(gameGlobals.Path.Unit)
+		if u == nil {
+			cobra.CheckErr(fmt.Errorf("unknown unit %q", gameGlobals.Path.Unit))
+		}
+		from, to := g.Map.Lookup(u.Hex), g.Map.Lookup(gameGlobals.Path.To)
+		if from == nil {
+			cobra.CheckErr(fmt.Errorf("unit %q is not on the map", u.Id))
+		} else if to == nil {
+			cobra.CheckErr(fmt.Errorf("unknown hex %q", gameGlobals.Path.To))
+		}
+
+		enemy := zoc.Compute(g, u.Side().Enemy())
+		cost := enemy.Cost(g.Map, zoc.Default, route.ClassCost(engine, u.Class))
+		path, err := route.New(g.Map, cost, engine.MinCost(u.Class)).Shortest(from, to)
+		cobra.CheckErr(err)
+		total := 0.0
+		for i, hex := range path.Hexes {
+			total += path.Costs[i]
+			note := ""
+			if enemy.In(hex) {
+				note = " (enemy zone of control)"
+			}
+			fmt.Printf("%3d %s %5.1f %6.1f %s%s\n", i, hex.Label, path.Costs[i], total, hex.Terrain, note)
+		}
+		fmt.Printf("%s from %s to %s: %d hexes, %.1f of %d capability points\n", u.Id, from.Label, to.Label, len(path.Hexes)-1, path.Total, u.CPA)
+	},
+}
+
+var gameZOCCmd = &cobra.Command{
+	Use:   "zoc",
+	Short: "report a side's zone of control",
+	Long: `List the hexes in a side's zone of control. The zone can also be
+written as an SVG overlay on the board.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		side, err := model.ParseSide(gameGlobals.ZOC.Side)
+		cobra.CheckErr(err)
+		if side == model.NoSide {
+			cobra.CheckErr(fmt.Errorf("missing side"))
+		}
+		g, err := jsondb.LoadGame(gameGlobals.ZOC.Game)
+		cobra.CheckErr(err)
+
+		z := zoc.Compute(g, side)
+		hexes := z.Hexes()
+		if verboseFlag {
+			for _, hex := range hexes {
+				fmt.Println(hex.Label)
+			}
+		}
+		fmt.Printf("%d hexes are in the %s zone of control\n", len(hexes), side)
+
+		if gameGlobals.ZOC.Output != "" {
+			ds := memory.New(g.Map)
+			cobra.CheckErr(ioutil.WriteFile(gameGlobals.ZOC.Output, []byte(ds.BoardAsOverlaySVG(z).String()), 0644))
+			log.Printf("[game] zoc: wrote %q\n", gameGlobals.ZOC.Output)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gameCmd)
+	gameCmd.AddCommand(gameNewCmd)
+	gameNewCmd.Flags().StringVar(&gameGlobals.New.Scenario, "scenario", "", "name of the scenario file")
+	gameNewCmd.Flags().StringVar(&gameGlobals.New.Output, "out", "game.json", "name of the game file to create")
+	gameNewCmd.Flags().StringVar(&gameGlobals.New.Seed, "seed", "", "seed for the dice (default is random)")
+	gameCmd.AddCommand(gamePathCmd)
+	gamePathCmd.Flags().StringVar(&gameGlobals.Path.Game, "game", "game.json", "name of the game file")
+	gamePathCmd.Flags().StringVar(&gameGlobals.Path.Unit, "unit", "", "id of the unit to move")
+	gamePathCmd.Flags().StringVar(&gameGlobals.Path.To, "to", "", "label of the destination hex")
+	gamePathCmd.Flags().StringVar(&gameGlobals.Path.Costs, "costs", "", "file name of a movement cost table (default is built-in)")
+	gameCmd.AddCommand(gameZOCCmd)
+	gameZOCCmd.Flags().StringVar(&gameGlobals.ZOC.Game, "game", "game.json", "name of the game file")
+	gameZOCCmd.Flags().StringVar(&gameGlobals.ZOC.Side, "side", "", "side exerting the zone of control")
+	gameZOCCmd.Flags().StringVar(&gameGlobals.ZOC.Output, "output", "", "file name to write the SVG overlay to")
+	gameCmd.AddCommand(gameMapCmd)
+	gameMapCmd.Flags().StringVar(&gameGlobals.Map.Game, "game", "game.json", "name of the game file")
+	gameMapCmd.Flags().StringVar(&gameGlobals.Map.Side, "side", "", "side to draw the map for")
+	gameMapCmd.Flags().StringVar(&gameGlobals.Map.Format, "format", "svg", "file format for the map (png or svg)")
+	gameMapCmd.Flags().StringVar(&gameGlobals.Map.Output, "out", "", "name of the file to write")
+}
@@ -0,0 +1,145 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/rail"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/spf13/cobra"
+	"os"
+	"text/tabwriter"
+)
+
+var logisticsGlobals struct {
+	Game string // name of the game file
+	Side string // leave blank for both sides
+	Turn int    // game-turn to list, zero for every turn
+}
+
+var logisticsCmd = &cobra.Command{
+	Use:   "logistics",
+	Short: "supply bookkeeping commands",
+	Long:  `Commands to report the fuel, ammunition, stores and water held in a game and the railroad that moves them.`,
+}
+
+var logisticsBalancesCmd = &cobra.Command{
+	Use:   "balances",
+	Short: "report the stock held by each dump and unit",
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(logisticsGlobals.Game)
+		cobra.CheckErr(err)
+		sides, err := logisticsSides()
+		cobra.CheckErr(err)
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		_, _ = fmt.Fprintf(tw, "Side\tHolder\tHex\tFuel\tAmmo\tStores\tWater\t\n")
+		for _, side := range sides {
+			for _, b := range logistics.Balances(g, side) {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t\n", side, b.Id, b.Hex, b.Fuel(), b.Ammo(), b.Stores(), b.Water())
+			}
+		}
+		_ = tw.Flush()
+	},
+}
+
+var logisticsLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "list the ledger entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(logisticsGlobals.Game)
+		cobra.CheckErr(err)
+		sides, err := logisticsSides()
+		cobra.CheckErr(err)
+		want := make(map[model.SIDE]bool)
+		for _, side := range sides {
+			want[side] = true
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Turn\tStage\tPhase\tSide\tKind\tAmount\tSupply\tFrom\tTo\tNote\n")
+		for _, e := range g.Ledger {
+			if !want[e.Side] || (logisticsGlobals.Turn != 0 && e.Turn != logisticsGlobals.Turn) {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", e.Turn, e.Stage, e.Phase, e.Side, e.Kind, e.Amount, e.Supply, e.From, e.To, e.Note)
+		}
+		_ = tw.Flush()
+	},
+}
+
+var logisticsRailCmd = &cobra.Command{
+	Use:   "rail",
+	Short: "report the railroad network, railheads and construction",
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(logisticsGlobals.Game)
+		cobra.CheckErr(err)
+		sides, err := logisticsSides()
+		cobra.CheckErr(err)
+
+		hexes := rail.Network(g.Map).Hexes()
+		fmt.Printf("%d hexes are on the railroad network\n", len(hexes))
+		if verboseFlag {
+			for _, hex := range hexes {
+				fmt.Println(hex.Label)
+			}
+		}
+		for _, side := range sides {
+			railhead := "none"
+			if p := g.Player(side); p != nil && p.Railhead != "" {
+				railhead = p.Railhead
+			}
+			fmt.Printf("%s: railhead %s, %d of %d tons of rail capacity left this game-turn\n", side, railhead, rail.Left(g, rail.Default, side), rail.Default.Capacity)
+			for _, w := range g.Rail {
+				if w.Side != side {
+					continue
+				}
+				status := fmt.Sprintf("%d of %d game-turns of work done", w.Turns, rail.Default.Turns)
+				if w.Done {
+					status = "finished"
+				}
+				fmt.Printf("%s: railroad on the %s side of %s: %s\n", side, w.Direction, w.Hex, status)
+			}
+		}
+	},
+}
+
+// logisticsSides returns the sides named by the side flag.
+func logisticsSides() ([]model.SIDE, error) {
+	if logisticsGlobals.Side == "" {
+		return []model.SIDE{model.Axis, model.Commonwealth}, nil
+	}
+	side, err := model.ParseSide(logisticsGlobals.Side)
+	if err != nil {
+		return nil, err
+	}
+	return []model.SIDE{side}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(logisticsCmd)
+	logisticsCmd.PersistentFlags().StringVar(&logisticsGlobals.Game, "game", "game.json", "name of the game file")
+	logisticsCmd.PersistentFlags().StringVar(&logisticsGlobals.Side, "side", "", "side to report on (default is both)")
+	logisticsCmd.AddCommand(logisticsBalancesCmd)
+	logisticsCmd.AddCommand(logisticsLedgerCmd)
+	logisticsCmd.AddCommand(logisticsRailCmd)
+	logisticsLedgerCmd.Flags().IntVar(&logisticsGlobals.Turn, "turn", 0, "game-turn to list (default is every game-turn)")
+}
@@ -19,11 +19,14 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"github.com/mdhender/tcfna/internal/route"
 	"github.com/mdhender/tcfna/internal/store/csvdb"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
 	"github.com/mdhender/tcfna/internal/store/memory"
+	"github.com/mdhender/tcfna/internal/supply"
 	"github.com/spf13/cobra"
 	"io/ioutil"
 	"log"
@@ -39,6 +42,20 @@ var mapGlobals struct {
 		Format string // html, json, png, svg
 		Name   string // leave blank to avoid export
 	}
+	Validate struct {
+		Output string // leave blank to avoid writing a reconciled board
+	}
+	Path struct {
+		From, To string // hex labels
+		Class    string // movement class
+		Costs    string // leave blank to use the built-in cost table
+	}
+	Supply struct {
+		Sources []string // hex labels of supply sources
+		Enemy   []string // hex labels of enemy-controlled hexes
+		Limit   float64  // longest trace allowed
+		Output  string   // leave blank to avoid writing the overlay
+	}
 }
 
 var mapCmd = &cobra.Command{
@@ -49,25 +66,9 @@ var mapCmd = &cobra.Command{
 		//rootDir := viper.Get("files.path").(string)
 		//log.Printf("[map] rootDir %q\n", rootDir)
 
-		var board *model.MAP
-		var err error
-
 		log.Println(mapGlobals)
-		if mapGlobals.Import.Name != "" {
-			switch mapGlobals.Import.Format {
-			case "csv":
-				board, err = csvdb.Convert(mapGlobals.Import.Name)
-				cobra.CheckErr(err)
-			case "json":
-				//board, err = jsondb.Convert(mapGlobals.Import.Name)
-				//cobra.CheckErr(err)
-			default:
-				log.Fatalf("[map] unsupported import format %q\n", mapGlobals.Import.Format)
-			}
-		}
-		if board == nil {
-			cobra.CheckErr(fmt.Errorf("missing input file name"))
-		}
+		board, err := loadBoard()
+		cobra.CheckErr(err)
 
 		ds := memory.New(board)
 
@@ -79,14 +80,9 @@ var mapCmd = &cobra.Command{
 				if mapGlobals.Import.Name == mapGlobals.Export.Name {
 					log.Fatal("[map] cowardly refusing to overwrite input file\n")
 				}
-				data := struct {
-					Data model.HEXES `json:"data"`
-				}{Data: board.Sorted}
-				b, err := json.MarshalIndent(data, "", "  ")
-				if err != nil {
+				if err := jsondb.Write(filepath.Join("..", "data", "board.json"), board); err != nil {
 					log.Fatalf("[map] encoding json: %+v\n", err)
 				}
-				cobra.CheckErr(ioutil.WriteFile(filepath.Join("..", "data", "board.json"), b, 0644))
 				ds.BoardAsImage(true)
 			case "png":
 				ds.BoardAsImage(true)
@@ -99,10 +95,173 @@ var mapCmd = &cobra.Command{
 	},
 }
 
+// loadBoard reads the board from the file named in the import flags.
+func loadBoard() (*model.MAP, error) {
+	if mapGlobals.Import.Name == "" {
+		return nil, fmt.Errorf("missing input file name")
+	}
+	switch mapGlobals.Import.Format {
+	case "csv":
+		return csvdb.Convert(mapGlobals.Import.Name)
+	case "json":
+		return jsondb.Convert(mapGlobals.Import.Name)
+	}
+	return nil, fmt.Errorf("unsupported import format %q", mapGlobals.Import.Format)
+}
+
+var mapValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "check hexsides shared by adjacent hexes",
+	Long: `Every hexside is recorded twice, once by each hex that shares it.
+Validate reports sides where the transport or water codes differ and
+where the elevation codes are not mirror images of each other.
+
+If an output file is given, sides where one hex is blank are copied
+from the other hex and the reconciled board is written as JSON.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		board, err := loadBoard()
+		cobra.CheckErr(err)
+
+		mismatches := board.CheckHexsides()
+		for _, mm := range mismatches {
+			fmt.Println(mm)
+		}
+		log.Printf("[map] validate: %d mismatched hexsides\n", len(mismatches))
+		if len(mismatches) == 0 {
+			return
+		}
+
+		if mapGlobals.Validate.Output == "" {
+			cobra.CheckErr(fmt.Errorf("%d mismatched hexsides", len(mismatches)))
+		} else if mapGlobals.Import.Name == mapGlobals.Validate.Output {
+			log.Fatal("[map] cowardly refusing to overwrite input file\n")
+		}
+
+		fixed, unresolved := board.Reconcile()
+		log.Printf("[map] validate: reconciled %d hexsides\n", fixed)
+		cobra.CheckErr(jsondb.Write(mapGlobals.Validate.Output, board))
+		log.Printf("[map] validate: wrote %q\n", mapGlobals.Validate.Output)
+		if len(unresolved) != 0 {
+			for _, mm := range unresolved {
+				fmt.Println("unresolved:", mm)
+			}
+			cobra.CheckErr(fmt.Errorf("%d conflicting hexsides need to be fixed by hand", len(unresolved)))
+		}
+	},
+}
+
+var mapPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "find the cheapest path between two hexes",
+	Long:  `Find and print the cheapest path between two hexes for a movement class.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		board, err := loadBoard()
+		cobra.CheckErr(err)
+		engine, err := loadMovement(board, mapGlobals.Path.Costs)
+		cobra.CheckErr(err)
+		class, err := model.ParseClass(mapGlobals.Path.Class)
+		cobra.CheckErr(err)
+
+		from, to := board.Lookup(mapGlobals.Path.From), board.Lookup(mapGlobals.Path.To)
+		if from == nil {
+			cobra.CheckErr(fmt.Errorf("unknown hex %q", mapGlobals.Path.From))
+		} else if to == nil {
+			cobra.CheckErr(fmt.Errorf("unknown hex %q", mapGlobals.Path.To))
+		}
+
+		path, err := route.ForClass(board, engine, class).Shortest(from, to)
+		cobra.CheckErr(err)
+		total := 0.0
+		for i, hex := range path.Hexes {
+			total += path.Costs[i]
+			fmt.Printf("%3d %s %5.1f %6.1f %s\n", i, hex.Label, path.Costs[i], total, hex.Terrain)
+		}
+		fmt.Printf("%s to %s: %d hexes, %.1f capability points\n", from.Label, to.Label, len(path.Hexes)-1, path.Total)
+	},
+}
+
+var mapSupplyCmd = &cobra.Command{
+	Use:   "supply",
+	Short: "trace supply from sources",
+	Long: `Trace lines of communication from supply sources, avoiding
+enemy-controlled hexes, and report the hexes that are in supply.
+The result can be written as an SVG overlay on the board.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		board, err := loadBoard()
+		cobra.CheckErr(err)
+
+		var sources []*model.HEX
+		for _, label := range mapGlobals.Supply.Sources {
+			hex := board.Lookup(label)
+			if hex == nil {
+				cobra.CheckErr(fmt.Errorf("unknown source hex %q", label))
+			}
+			sources = append(sources, hex)
+		}
+		enemy := make(map[*model.HEX]bool)
+		for _, label := range mapGlobals.Supply.Enemy {
+			hex := board.Lookup(label)
+			if hex == nil {
+				cobra.CheckErr(fmt.Errorf("unknown enemy hex %q", label))
+			}
+			enemy[hex] = true
+		}
+
+		rules := supply.Default
+		rules.Limit = mapGlobals.Supply.Limit
+		result := supply.Trace(board, rules, sources, enemy)
+		inSupply := result.InSupply()
+		if verboseFlag {
+			for _, hex := range inSupply {
+				status := result.Status(hex)
+				fmt.Printf("%s %6.2f %3d %s\n", hex.Label, status.Length, status.Hexes, status.Source.Label)
+			}
+		}
+		fmt.Printf("%d of %d hexes are in supply\n", len(inSupply), len(board.Sorted))
+
+		if mapGlobals.Supply.Output != "" {
+			ds := memory.New(board)
+			cobra.CheckErr(ioutil.WriteFile(mapGlobals.Supply.Output, []byte(ds.BoardAsOverlaySVG(result).String()), 0644))
+			log.Printf("[map] supply: wrote %q\n", mapGlobals.Supply.Output)
+		}
+	},
+}
+
+// loadMovement returns a movement engine using the named cost table.
+// If the name is blank, the built-in table is used.
+func loadMovement(board *model.MAP, name string) (*movement.ENGINE, error) {
+	var table *movement.TABLE
+	var err error
+	if name == "" {
+		table, err = movement.Default()
+	} else {
+		table, err = movement.Load(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return movement.New(board, table), nil
+}
+
 func init() {
 	rootCmd.AddCommand(mapCmd)
-	mapCmd.Flags().StringVar(&mapGlobals.Import.Name, "import", "", "file name to read board map data from")
-	mapCmd.Flags().StringVar(&mapGlobals.Import.Format, "import-format", "json", "file format for imported data")
+	mapCmd.PersistentFlags().StringVar(&mapGlobals.Import.Name, "import", "", "file name to read board map data from")
+	mapCmd.PersistentFlags().StringVar(&mapGlobals.Import.Format, "import-format", "json", "file format for imported data")
 	mapCmd.Flags().StringVar(&mapGlobals.Export.Name, "export", "", "file name to write board map data to")
 	mapCmd.Flags().StringVar(&mapGlobals.Export.Format, "export-format", "png", "file format for exported data")
+
+	mapCmd.AddCommand(mapValidateCmd)
+	mapValidateCmd.Flags().StringVar(&mapGlobals.Validate.Output, "output", "", "file name to write the reconciled board to")
+
+	mapCmd.AddCommand(mapPathCmd)
+	mapPathCmd.Flags().StringVar(&mapGlobals.Path.From, "from", "", "label of the starting hex")
+	mapPathCmd.Flags().StringVar(&mapGlobals.Path.To, "to", "", "label of the destination hex")
+	mapPathCmd.Flags().StringVar(&mapGlobals.Path.Class, "class", "motorized", "movement class (motorized, non-motorized, track, foot)")
+	mapPathCmd.Flags().StringVar(&mapGlobals.Path.Costs, "costs", "", "file name of a movement cost table (default is built-in)")
+
+	mapCmd.AddCommand(mapSupplyCmd)
+	mapSupplyCmd.Flags().StringSliceVar(&mapGlobals.Supply.Sources, "sources", nil, "labels of the supply source hexes")
+	mapSupplyCmd.Flags().StringSliceVar(&mapGlobals.Supply.Enemy, "enemy", nil, "labels of the enemy-controlled hexes")
+	mapSupplyCmd.Flags().Float64Var(&mapGlobals.Supply.Limit, "limit", supply.Default.Limit, "longest trace allowed, in cross-country hexes")
+	mapSupplyCmd.Flags().StringVar(&mapGlobals.Supply.Output, "output", "", "file name to write the SVG overlay to")
 }
@@ -0,0 +1,213 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/combat"
+	"github.com/mdhender/tcfna/internal/executor"
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/orders"
+	"github.com/mdhender/tcfna/internal/rail"
+	"github.com/mdhender/tcfna/internal/stacking"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/mdhender/tcfna/internal/zoc"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+var ordersGlobals struct {
+	Game    string // name of the game file
+	Costs   string // leave blank to use the built-in cost table
+	Combat  string // leave blank to use the built-in combat tables
+	Execute struct {
+		Output  string // name of the new game file
+		Results string // name of the results log
+	}
+}
+
+var ordersCmd = &cobra.Command{
+	Use:   "orders",
+	Short: "player order commands",
+	Long:  `Commands to check and execute player orders.`,
+}
+
+var ordersExecuteCmd = &cobra.Command{
+	Use:   "execute orders.txt...",
+	Short: "execute order files for the current phase",
+	Long: `Execute each player's orders for the current phase. The updated
+game is written to a new file and the result of every order is written
+to the results log. The original game file is never changed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, results := ordersGlobals.Execute.Output, ordersGlobals.Execute.Results
+		if output == "" {
+			cobra.CheckErr(fmt.Errorf("missing --out"))
+		} else if results == "" {
+			cobra.CheckErr(fmt.Errorf("missing --results"))
+		}
+		if same, err := sameFile(ordersGlobals.Game, output); err != nil {
+			cobra.CheckErr(err)
+		} else if same {
+			cobra.CheckErr(fmt.Errorf("--out must not replace the game file %q", ordersGlobals.Game))
+		}
+
+		g, err := jsondb.LoadGame(ordersGlobals.Game)
+		cobra.CheckErr(err)
+		engine, err := loadMovement(g.Map, ordersGlobals.Costs)
+		cobra.CheckErr(err)
+
+		var files []*orders.FILE
+		for _, name := range args {
+			f, err := parseOrders(name)
+			if err != nil {
+				reportOrderErrors(name, err)
+				cobra.CheckErr(fmt.Errorf("%s: can not be parsed", name))
+			}
+			files = append(files, f)
+		}
+
+		tables, err := loadCombat(ordersGlobals.Combat)
+		cobra.CheckErr(err)
+		rules := executor.RULES{
+			Movement: engine,
+			Combat:   combat.New(g.Map, tables),
+			ZOC:      zoc.Default,
+			Stacking: stacking.Default,
+			Fuel:     logistics.DefaultFuel,
+			Trucks:   logistics.DefaultTrucks,
+			Rail:     rail.Default,
+		}
+
+		r, err := executor.Execute(g, rules, files...)
+		cobra.CheckErr(err)
+		cobra.CheckErr(jsondb.WriteGame(output, g))
+		log.Printf("[orders] execute: wrote %q\n", output)
+		cobra.CheckErr(jsondb.WriteResults(results, r))
+		log.Printf("[orders] execute: wrote %q\n", results)
+
+		counts := make(map[model.STATUS]int)
+		for _, result := range r.Results {
+			counts[result.Status]++
+			if verboseFlag || result.Status != model.Success {
+				fmt.Printf("%s:%d: %s: %s", result.Side, result.Line, result.Status, result.Order)
+				if result.Reason != "" {
+					fmt.Printf(": %s", result.Reason)
+				}
+				fmt.Println()
+			}
+		}
+		for _, v := range r.Violations {
+			fmt.Printf("%s: %s\n", v.Rule, v)
+		}
+		fmt.Printf("%d orders: %d success, %d partial, %d rejected\n", len(r.Results), counts[model.Success], counts[model.Partial], counts[model.Rejected])
+	},
+}
+
+var ordersCheckCmd = &cobra.Command{
+	Use:   "check orders.txt...",
+	Short: "check order files without executing them",
+	Long: `Parse each order file and check the orders against the current
+state of the game. Nothing is changed. Orders that depend on an earlier
+order in the same file, like building a dump where a unit is moving to,
+may be reported here and still succeed when the orders are executed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(ordersGlobals.Game)
+		cobra.CheckErr(err)
+		engine, err := loadMovement(g.Map, ordersGlobals.Costs)
+		cobra.CheckErr(err)
+
+		bad := 0
+		for _, name := range args {
+			f, err := parseOrders(name)
+			if err == nil {
+				err = orders.Check(g, engine, f)
+			}
+			if err != nil {
+				bad++
+				reportOrderErrors(name, err)
+				continue
+			}
+			log.Printf("[orders] %s: %d %s orders ok\n", name, len(f.Orders), f.Side)
+		}
+		if bad != 0 {
+			cobra.CheckErr(fmt.Errorf("%d of %d order files have errors", bad, len(args)))
+		}
+	},
+}
+
+// parseOrders reads and parses an order file.
+func parseOrders(name string) (*orders.FILE, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return orders.Parse(b)
+}
+
+// loadCombat returns the named combat tables.
+// If the name is blank, the built-in tables are used.
+func loadCombat(name string) (*combat.TABLES, error) {
+	if name == "" {
+		return combat.Default()
+	}
+	return combat.Load(name)
+}
+
+// sameFile returns true if both names refer to the same file.
+func sameFile(a, b string) (bool, error) {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := os.Stat(b)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return os.SameFile(sa, sb), nil
+}
+
+// reportOrderErrors prints errors prefixed with the file name
+// so that editors can jump to the line.
+func reportOrderErrors(name string, err error) {
+	if errs, ok := err.(orders.ERRORS); ok {
+		for _, e := range errs {
+			fmt.Printf("%s:%s\n", name, e)
+		}
+		return
+	}
+	fmt.Printf("%s: %v\n", name, err)
+}
+
+func init() {
+	rootCmd.AddCommand(ordersCmd)
+	ordersCmd.PersistentFlags().StringVar(&ordersGlobals.Game, "game", "game.json", "name of the game file")
+	ordersCmd.PersistentFlags().StringVar(&ordersGlobals.Costs, "costs", "", "file name of a movement cost table (default is built-in)")
+	ordersCmd.AddCommand(ordersCheckCmd)
+	ordersCmd.AddCommand(ordersExecuteCmd)
+	ordersExecuteCmd.Flags().StringVar(&ordersGlobals.Combat, "combat", "", "file name of the combat tables (default is built-in)")
+	ordersExecuteCmd.Flags().StringVar(&ordersGlobals.Execute.Output, "out", "", "name of the game file to write")
+	ordersExecuteCmd.Flags().StringVar(&ordersGlobals.Execute.Results, "results", "", "name of the results log to write")
+}
@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/report"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/mdhender/tcfna/internal/store/memory"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+)
+
+var reportGlobals struct {
+	Game    string // name of the game file
+	Results string // name of the results log, may be blank
+	Side    string // leave blank to report on both sides
+	Output  string // folder to write the reports to
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "generate player reports",
+	Long: `Generate the turn report for each player as plain text and HTML.
+The report lists the player's units and their supply status, the results
+of the player's orders, and the enemy units the player can see.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(reportGlobals.Game)
+		cobra.CheckErr(err)
+		var results *model.RESULTS
+		if reportGlobals.Results != "" {
+			results, err = jsondb.LoadResults(reportGlobals.Results)
+			cobra.CheckErr(err)
+		}
+
+		sides := []model.SIDE{model.Axis, model.Commonwealth}
+		if reportGlobals.Side != "" {
+			side, err := model.ParseSide(reportGlobals.Side)
+			cobra.CheckErr(err)
+			sides = []model.SIDE{side}
+		}
+
+		ds := memory.New(g.Map)
+		for _, side := range sides {
+			r := report.New(g, results, side)
+			base := filepath.Join(reportGlobals.Output, fmt.Sprintf("t%02d-s%d-%s", g.Turn, g.Stage, side))
+
+			b := &bytes.Buffer{}
+			cobra.CheckErr(r.Text(b))
+			cobra.CheckErr(ioutil.WriteFile(base+".txt", b.Bytes(), 0644))
+			log.Printf("[report] wrote %q\n", base+".txt")
+
+			page, err := r.HTML(ds)
+			cobra.CheckErr(err)
+			cobra.CheckErr(ioutil.WriteFile(base+".html", page, 0644))
+			log.Printf("[report] wrote %q\n", base+".html")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&reportGlobals.Game, "game", "game.json", "name of the game file")
+	reportCmd.Flags().StringVar(&reportGlobals.Results, "results", "", "name of the results log for the phase")
+	reportCmd.Flags().StringVar(&reportGlobals.Side, "side", "", "side to report on (default is both)")
+	reportCmd.Flags().StringVar(&reportGlobals.Output, "output", ".", "folder to write the reports to")
+}
@@ -0,0 +1,127 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/procedure"
+	"github.com/mdhender/tcfna/internal/store/jsondb"
+	"github.com/mdhender/tcfna/internal/turn"
+	"github.com/spf13/cobra"
+	"log"
+	"strings"
+)
+
+var turnGlobals struct {
+	Game    string // name of the game file
+	Output  string // name of the game file to write, never the game file
+	Results string // name of the results log for automatic procedures, may be blank
+}
+
+var turnCmd = &cobra.Command{
+	Use:   "turn",
+	Short: "sequence of play commands",
+	Long:  `Commands to report and advance the sequence of play for a saved game.`,
+}
+
+var turnStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "report the current phase",
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := jsondb.LoadGame(turnGlobals.Game)
+		cobra.CheckErr(err)
+		printStatus(g)
+	},
+}
+
+var turnAdvanceCmd = &cobra.Command{
+	Use:   "advance",
+	Short: "advance to the next phase",
+	Long: `Advance the game to the next phase and run its automatic procedures.
+The updated game is written to a new file. The original game file is
+never changed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := turnGlobals.Output
+		if output == "" {
+			cobra.CheckErr(fmt.Errorf("missing --out"))
+		}
+		if same, err := sameFile(turnGlobals.Game, output); err != nil {
+			cobra.CheckErr(err)
+		} else if same {
+			cobra.CheckErr(fmt.Errorf("--out must not replace the game file %q", turnGlobals.Game))
+		}
+
+		g, err := jsondb.LoadGame(turnGlobals.Game)
+		cobra.CheckErr(err)
+		_, results, err := procedure.Advance(g, procedure.Default)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(jsondb.WriteGame(output, g))
+		log.Printf("[turn] advance: wrote %q\n", output)
+		if turnGlobals.Results != "" {
+			cobra.CheckErr(jsondb.WriteResults(turnGlobals.Results, results))
+			log.Printf("[turn] advance: wrote %q\n", turnGlobals.Results)
+		}
+		for _, r := range results.Results {
+			if verboseFlag || r.Status != model.Success {
+				fmt.Printf("%s: %s: %s", r.Side, r.Status, r.Order)
+				if r.Reason != "" {
+					fmt.Printf(": %s", r.Reason)
+				}
+				fmt.Println()
+			}
+		}
+		printStatus(g)
+	},
+}
+
+// printStatus reports the game's position in the sequence of play.
+func printStatus(g *model.GAME) {
+	step, err := turn.Current(g)
+	cobra.CheckErr(err)
+	phasing, err := turn.Phasing(g)
+	cobra.CheckErr(err)
+
+	fmt.Printf("game-turn: %d\n", g.Turn)
+	if step.Stage != 0 {
+		fmt.Printf("stage:     %d\n", step.Stage)
+	}
+	fmt.Printf("phase:     %s\n", step.Phase.Name)
+	switch step.Phase.Player {
+	case turn.Automatic:
+		fmt.Printf("phasing:   none (moderator)\n")
+	case turn.Both:
+		fmt.Printf("phasing:   both players\n")
+	default:
+		fmt.Printf("phasing:   %s\n", phasing)
+	}
+	if len(step.Phase.Orders) != 0 {
+		fmt.Printf("orders:    %s\n", strings.Join(step.Phase.Orders, ", "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(turnCmd)
+	turnCmd.PersistentFlags().StringVar(&turnGlobals.Game, "game", "game.json", "name of the game file")
+	turnCmd.AddCommand(turnStatusCmd)
+	turnCmd.AddCommand(turnAdvanceCmd)
+	turnAdvanceCmd.Flags().StringVar(&turnGlobals.Results, "results", "", "name of the results log to write for automatic procedures")
+	turnAdvanceCmd.Flags().StringVar(&turnGlobals.Output, "out", "", "name of the game file to write")
+}
@@ -0,0 +1,339 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package combat resolves close assaults and barrages.
+//
+// Resolution is split in two. CloseAssault and Barrage work out the
+// column, roll the dice and look up the result without changing any
+// unit. Apply then takes the losses, reduces cohesion and retreats the
+// defenders. Every step is kept in the RESULT so that it can be audited.
+package combat
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"sort"
+	"strings"
+)
+
+// ENGINE resolves combat on a board.
+type ENGINE struct {
+	board  *model.MAP
+	tables *TABLES
+}
+
+// New returns an engine for the board using the given tables.
+func New(board *model.MAP, tables *TABLES) *ENGINE {
+	return &ENGINE{board: board, tables: tables}
+}
+
+// RESULT is the full record of one combat.
+type RESULT struct {
+	Kind      string // "close assault" or "barrage"
+	Target    *model.HEX
+	Attackers model.UNITS
+	Defenders model.UNITS
+	Attack    int     // attack strength
+	Defense   int     // defense strength, zero for a barrage
+	Value     int     // differential for a close assault, strength for a barrage
+	Shifts    []SHIFT // column shifts applied
+	Column    int     // index of the column used, after shifts
+	Roll      *model.ROLL
+	Code      string // result code from the table
+	Outcome   OUTCOME
+	Losses    []LOSS   // filled in by Apply
+	Retreat   []string // labels of the hexes the defenders retreated through, filled in by Apply
+}
+
+// SHIFT is a column shift and the reason for it.
+type SHIFT struct {
+	Reason  string
+	Columns int
+}
+
+// LOSS is what one unit lost in a combat.
+type LOSS struct {
+	Unit       string
+	TOE        int
+	Cohesion   int
+	Eliminated bool
+}
+
+// CloseAssault resolves an assault by the attackers on the target hex.
+// Every attacker must be adjacent to the target. The column is found
+// from the difference between the attack and defense strengths, then
+// shifted for the terrain in the target and for the worst hexside that
+// any attacker crosses.
+func (e *ENGINE) CloseAssault(attackers model.UNITS, target *model.HEX, defenders model.UNITS, roller *dice.ROLLER, ctx string) (*RESULT, error) {
+	if len(defenders) == 0 {
+		return nil, fmt.Errorf("no defenders in %s", target.Label)
+	}
+	r := &RESULT{Kind: "close assault", Target: target, Attackers: attackers, Defenders: defenders}
+	r.Attack, r.Defense = strength(attackers), strength(defenders)
+	r.Value = r.Attack - r.Defense
+
+	r.shift(target.Terrain.String(), e.tables.Terrain[target.Terrain])
+	var worst *SHIFT
+	for _, u := range attackers {
+		from := e.board.Lookup(u.Hex)
+		if from == nil {
+			return nil, fmt.Errorf("unit %q is not on the map", u.Id)
+		}
+		d, ok := e.board.Adjacent(from, target)
+		if !ok {
+			return nil, fmt.Errorf("unit %q in %s is not adjacent to %s", u.Id, u.Hex, target.Label)
+		}
+		side := e.board.Hexside(from, d)
+		s := SHIFT{Reason: fmt.Sprintf("hexside %s %s", from.Label, d), Columns: e.tables.Elevation[side.Elevation] + e.tables.Water[side.Water]}
+		if side.Elevation != model.NoElevation || side.Water != model.NoWater {
+			s.Reason += fmt.Sprintf(" (%s)", strings.TrimSpace(fmt.Sprintf("%s %s", side.Elevation, side.Water)))
+		}
+		if worst == nil || s.Columns < worst.Columns {
+			worst = &s
+		}
+	}
+	if worst != nil {
+		r.shift(worst.Reason, worst.Columns)
+	}
+
+	if err := r.resolve(&e.tables.CloseAssault, roller, ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Barrage resolves a barrage by the artillery on the target hex.
+// Every unit must be artillery within range of the target. The column
+// is found from the total strength of the artillery, then shifted for
+// the terrain in the target.
+func (e *ENGINE) Barrage(artillery model.UNITS, target *model.HEX, defenders model.UNITS, roller *dice.ROLLER, ctx string) (*RESULT, error) {
+	if len(defenders) == 0 {
+		return nil, fmt.Errorf("no defenders in %s", target.Label)
+	}
+	for _, u := range artillery {
+		if u.Type != model.Artillery {
+			return nil, fmt.Errorf("unit %q is not artillery", u.Id)
+		} else if from := e.board.Lookup(u.Hex); from == nil || from.Distance(target) > e.tables.Barrage.Range {
+			return nil, fmt.Errorf("unit %q in %s is out of range of %s", u.Id, u.Hex, target.Label)
+		}
+	}
+	r := &RESULT{Kind: "barrage", Target: target, Attackers: artillery, Defenders: defenders}
+	r.Attack = strength(artillery)
+	r.Value = r.Attack
+	r.shift(target.Terrain.String(), e.tables.Terrain[target.Terrain])
+	if err := r.resolve(&e.tables.Barrage, roller, ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// shift records a column shift. Shifts of zero are not recorded.
+func (r *RESULT) shift(reason string, columns int) {
+	if columns != 0 {
+		r.Shifts = append(r.Shifts, SHIFT{Reason: reason, Columns: columns})
+	}
+}
+
+// resolve finds the column, rolls two dice and looks up the result.
+func (r *RESULT) resolve(t *TABLE, roller *dice.ROLLER, ctx string) (err error) {
+	r.Column = t.column(r.Value)
+	for _, s := range r.Shifts {
+		r.Column += s.Columns
+	}
+	if r.Column < 0 {
+		r.Column = 0
+	} else if r.Column >= len(t.Columns) {
+		r.Column = len(t.Columns) - 1
+	}
+	if r.Roll, err = roller.Roll(r.Defenders[0].Side().Enemy(), ctx, fmt.Sprintf("%s on %s", r.Kind, r.Target.Label), 2, 6); err != nil {
+		return err
+	}
+	r.Code = t.Results[r.Roll.Total()][r.Column]
+	r.Outcome, _ = ParseOutcome(r.Code) // the table was checked when it was loaded
+	return nil
+}
+
+// Apply takes the losses from the units, reduces their cohesion by one
+// for each TOE point lost and each hex retreated, and retreats the
+// defenders. Each defender that can not retreat loses a TOE point for
+// each hex it can not retreat. Units that lose all their TOE points are
+// eliminated and removed from the map.
+func (e *ENGINE) Apply(g *model.GAME, engine *movement.ENGINE, r *RESULT) {
+	losses := make(map[*model.UNIT]*LOSS)
+	lose := func(units model.UNITS, points int) {
+		for ; points > 0; points-- {
+			var worst *model.UNIT
+			for _, u := range units {
+				if u.TOE > 0 && (worst == nil || u.TOE > worst.TOE) {
+					worst = u
+				}
+			}
+			if worst == nil {
+				return
+			}
+			worst.TOE--
+			worst.Cohesion--
+			if losses[worst] == nil {
+				losses[worst] = &LOSS{Unit: worst.Id}
+			}
+			losses[worst].TOE++
+			losses[worst].Cohesion--
+		}
+	}
+
+	lose(r.Attackers, r.Outcome.Attacker)
+	lose(r.Defenders, r.Outcome.Defender)
+
+	if r.Outcome.Retreat > 0 {
+		var survivors model.UNITS
+		for _, u := range r.Defenders {
+			if u.TOE > 0 {
+				survivors = append(survivors, u)
+			}
+		}
+		path := e.retreat(g, engine, survivors, r)
+		for _, hex := range path {
+			r.Retreat = append(r.Retreat, hex.Label)
+		}
+		for _, u := range survivors {
+			if len(path) != 0 {
+				u.Hex = path[len(path)-1].Label
+			}
+			for range path {
+				u.Cohesion--
+				if losses[u] == nil {
+					losses[u] = &LOSS{Unit: u.Id}
+				}
+				losses[u].Cohesion--
+			}
+		}
+		for n := len(path); n < r.Outcome.Retreat; n++ {
+			for _, u := range survivors {
+				lose(model.UNITS{u}, 1)
+			}
+		}
+	}
+
+	for u, loss := range losses {
+		if u.TOE == 0 {
+			u.Hex, loss.Eliminated = "", true
+		}
+		r.Losses = append(r.Losses, *loss)
+	}
+	sort.Slice(r.Losses, func(i, j int) bool {
+		return r.Losses[i].Unit < r.Losses[j].Unit
+	})
+}
+
+// retreat returns the path the defenders retreat along. Each hex must
+// be farther from the attackers than the last, free of enemy units, and
+// enterable by every defender. The path stops short when no hex qualifies.
+func (e *ENGINE) retreat(g *model.GAME, engine *movement.ENGINE, units model.UNITS, r *RESULT) (path []*model.HEX) {
+	if len(units) == 0 {
+		return nil
+	}
+	var from []*model.HEX
+	for _, u := range r.Attackers {
+		if hex := e.board.Lookup(u.Hex); hex != nil {
+			from = append(from, hex)
+		}
+	}
+	distance := func(hex *model.HEX) int {
+		min := -1
+		for _, a := range from {
+			if d := a.Distance(hex); min == -1 || d < min {
+				min = d
+			}
+		}
+		return min
+	}
+	enemy := units[0].Side().Enemy()
+
+	at := r.Target
+	for len(path) < r.Outcome.Retreat {
+		var best *model.HEX
+		for _, d := range model.Directions {
+			to := e.board.Neighbor(at, d)
+			if to == nil || to.Terrain.Attributes().Sea || distance(to) <= distance(at) {
+				continue
+			}
+			blocked := false
+			for _, u := range g.UnitsAt(to.Label) {
+				blocked = blocked || u.Side() == enemy
+			}
+			for _, u := range units {
+				if _, err := engine.Cost(at, d, u.Class); err != nil {
+					blocked = true
+				}
+			}
+			if !blocked && (best == nil || distance(to) > distance(best)) {
+				best = to
+			}
+		}
+		if best == nil {
+			break
+		}
+		path = append(path, best)
+		at = best
+	}
+	return path
+}
+
+// Audit returns a line by line account of the combat.
+func (r *RESULT) Audit() (lines []string) {
+	var attackers, defenders []string
+	for _, u := range r.Attackers {
+		attackers = append(attackers, u.Id)
+	}
+	for _, u := range r.Defenders {
+		defenders = append(defenders, u.Id)
+	}
+	lines = append(lines, fmt.Sprintf("%s on %s by %s against %s", r.Kind, r.Target.Label, strings.Join(attackers, ", "), strings.Join(defenders, ", ")))
+	if r.Kind == "barrage" {
+		lines = append(lines, fmt.Sprintf("strength %d", r.Attack))
+	} else {
+		lines = append(lines, fmt.Sprintf("attack %d, defense %d, differential %+d", r.Attack, r.Defense, r.Value))
+	}
+	for _, s := range r.Shifts {
+		lines = append(lines, fmt.Sprintf("shift %+d for %s", s.Columns, s.Reason))
+	}
+	lines = append(lines, fmt.Sprintf("column %d, roll %d (%s), result %q", r.Column+1, r.Roll.Total(), r.Roll.Key(), r.Code))
+	for _, loss := range r.Losses {
+		line := fmt.Sprintf("%s loses %d TOE and %d cohesion", loss.Unit, loss.TOE, -loss.Cohesion)
+		if loss.Eliminated {
+			line += ", eliminated"
+		}
+		lines = append(lines, line)
+	}
+	if len(r.Retreat) != 0 {
+		lines = append(lines, fmt.Sprintf("defenders retreat to %s", strings.Join(r.Retreat, ", ")))
+	} else if r.Outcome.Retreat != 0 {
+		lines = append(lines, "defenders could not retreat")
+	}
+	return lines
+}
+
+// strength returns the total TOE strength of the units.
+func strength(units model.UNITS) (total int) {
+	for _, u := range units {
+		total += u.TOE
+	}
+	return total
+}
@@ -0,0 +1,165 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package combat
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+//go:embed tables.json
+var defaultTables []byte
+
+// TABLES is the data that drives combat resolution.
+//
+// Terrain, Elevation and Water are column shifts against the attacker.
+// Terrain is for the defender's hex. Elevation and Water are for the
+// hexside the attack crosses, with elevation as seen from the attacker.
+// Barrage only uses the terrain shifts.
+type TABLES struct {
+	CloseAssault TABLE                   `json:"close-assault"`
+	Barrage      TABLE                   `json:"barrage"`
+	Terrain      map[model.TERRAIN]int   `json:"terrain"`
+	Elevation    map[model.ELEVATION]int `json:"elevation"`
+	Water        map[model.WATER]int     `json:"water"`
+}
+
+// TABLE is a combat results table.
+//
+// Columns are the lowest value that falls in each column; values below
+// the first column use the first column. Results are indexed by the total
+// of two dice and hold one result code per column.
+type TABLE struct {
+	Range   int              `json:"range,omitempty"` // hexes, for barrage
+	Columns []int            `json:"columns"`
+	Results map[int][]string `json:"results"`
+}
+
+// OUTCOME is a decoded result code such as "A1 D2 R1".
+// A is TOE points lost by the attacker, D by the defender, and R is
+// hexes the defender must retreat. "-" is no effect.
+type OUTCOME struct {
+	Attacker int
+	Defender int
+	Retreat  int
+}
+
+// Default returns the tables that are built into the engine.
+func Default() (*TABLES, error) {
+	return decode(defaultTables)
+}
+
+// Load reads combat tables from a JSON file.
+func Load(name string) (*TABLES, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	t, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+func decode(b []byte) (*TABLES, error) {
+	t := &TABLES{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(t); err != nil {
+		return nil, err
+	}
+	if err := t.CloseAssault.check(); err != nil {
+		return nil, fmt.Errorf("close-assault: %w", err)
+	}
+	if err := t.Barrage.check(); err != nil {
+		return nil, fmt.Errorf("barrage: %w", err)
+	}
+	return t, nil
+}
+
+// check returns an error if the table is missing a row or column,
+// or has a result code that can not be decoded.
+func (t *TABLE) check() error {
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("missing columns")
+	}
+	for i := 1; i < len(t.Columns); i++ {
+		if t.Columns[i] <= t.Columns[i-1] {
+			return fmt.Errorf("columns must increase")
+		}
+	}
+	for roll := 2; roll <= 12; roll++ {
+		row, ok := t.Results[roll]
+		if !ok {
+			return fmt.Errorf("missing results for %d", roll)
+		} else if len(row) != len(t.Columns) {
+			return fmt.Errorf("results for %d: want %d columns, got %d", roll, len(t.Columns), len(row))
+		}
+		for _, code := range row {
+			if _, err := ParseOutcome(code); err != nil {
+				return fmt.Errorf("results for %d: %w", roll, err)
+			}
+		}
+	}
+	return nil
+}
+
+// column returns the index of the column for the value.
+func (t *TABLE) column(value int) (column int) {
+	for n, lowest := range t.Columns {
+		if value >= lowest {
+			column = n
+		}
+	}
+	return column
+}
+
+// ParseOutcome decodes a result code.
+func ParseOutcome(code string) (OUTCOME, error) {
+	var o OUTCOME
+	for _, field := range strings.Fields(code) {
+		if field == "-" {
+			continue
+		} else if len(field) < 2 {
+			return OUTCOME{}, fmt.Errorf("invalid result %q", code)
+		}
+		n, err := strconv.Atoi(field[1:])
+		if err != nil || n < 0 {
+			return OUTCOME{}, fmt.Errorf("invalid result %q", code)
+		}
+		switch field[0] {
+		case 'A':
+			o.Attacker += n
+		case 'D':
+			o.Defender += n
+		case 'R':
+			o.Retreat += n
+		default:
+			return OUTCOME{}, fmt.Errorf("invalid result %q", code)
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,186 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package dice throws reproducible dice for a game.
+//
+// Every roll is derived from the game's secret seed and the context of
+// the roll: the game-turn, stage and phase, what the roll is for, and
+// how many rolls were made before it in the same context. The same
+// game, seed and orders always produce the same rolls, so anyone
+// holding the seed can check that the referee did not cheat.
+//
+// Each die is drawn from HMAC-SHA256(seed, context), read as a stream
+// of 32-bit words. Words that would bias the result are skipped.
+package dice
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+)
+
+// NewSeed returns a random seed for a new game.
+func NewSeed() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Hash returns the SHA-256 hash of the seed. The hash is published to
+// the players when the game starts so that they can check the seed
+// when it is revealed.
+func Hash(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// ROLLER throws dice for a game and records them in the game.
+type ROLLER struct {
+	g     *model.GAME
+	index map[string]int // next index for each phase and context
+}
+
+// New returns a roller for the game. The game must have a seed.
+func New(g *model.GAME) (*ROLLER, error) {
+	if g.Seed == "" {
+		return nil, fmt.Errorf("game has no seed")
+	}
+	r := &ROLLER{g: g, index: make(map[string]int)}
+	for _, roll := range g.Rolls {
+		if key := group(roll); roll.Index >= r.index[key] {
+			r.index[key] = roll.Index + 1
+		}
+	}
+	return r, nil
+}
+
+// Roll throws n dice with the given number of sides for the side,
+// records the roll in the game, and returns it. Rolls that both sides
+// may see are made for NoSide.
+func (r *ROLLER) Roll(side model.SIDE, ctx, purpose string, n, sides int) (*model.ROLL, error) {
+	roll := &model.ROLL{
+		Turn:    r.g.Turn,
+		Stage:   r.g.Stage,
+		Phase:   r.g.Phase,
+		Side:    side,
+		Context: ctx,
+		Purpose: purpose,
+		Sides:   sides,
+	}
+	key := group(roll)
+	roll.Index = r.index[key]
+	dice, err := Throw(r.g.Seed, roll.Key(), n, sides)
+	if err != nil {
+		return nil, err
+	}
+	roll.Dice = dice
+	r.index[key]++
+	r.g.Rolls = append(r.g.Rolls, roll)
+	return roll, nil
+}
+
+// D6 throws a single six-sided die for the side and returns the result.
+func (r *ROLLER) D6(side model.SIDE, ctx, purpose string) (int, error) {
+	roll, err := r.Roll(side, ctx, purpose, 1, 6)
+	if err != nil {
+		return 0, err
+	}
+	return roll.Total(), nil
+}
+
+// Throw derives n dice with the given number of sides from the seed
+// and key. It is the only source of randomness in the game.
+// It returns an error if sides is less than 1 or more than 2^32, the
+// most that a 32-bit word can choose between, or if n is negative.
+func Throw(seed, key string, n, sides int) ([]int, error) {
+	if sides < 1 {
+		return nil, fmt.Errorf("%s: dice must have at least 1 side, not %d", key, sides)
+	} else if uint64(sides) > 1<<32 {
+		return nil, fmt.Errorf("%s: dice must have at most %d sides, not %d", key, uint64(1<<32), sides)
+	} else if n < 0 {
+		return nil, fmt.Errorf("%s: can not throw %d dice", key, n)
+	}
+
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(key))
+	block := mac.Sum(nil)
+
+	// reject words at or above the largest multiple of sides so that
+	// every face is equally likely.
+	limit := (1 << 32) / uint64(sides) * uint64(sides)
+
+	var dice []int
+	for counter := uint32(0); len(dice) < n; counter++ {
+		if counter != 0 && counter%8 == 0 {
+			// used up the block, so extend the stream
+			mac.Reset()
+			mac.Write(block)
+			block = mac.Sum(nil)
+		}
+		word := uint64(binary.BigEndian.Uint32(block[4*(counter%8):]))
+		if word < limit {
+			dice = append(dice, int(word%uint64(sides))+1)
+		}
+	}
+	return dice, nil
+}
+
+// Verify derives the roll again from the seed and returns an error
+// if the recorded dice do not match. A roll with no dice never matches.
+func Verify(seed string, roll *model.ROLL) error {
+	if len(roll.Dice) == 0 {
+		return fmt.Errorf("%s: no dice recorded", roll.Key())
+	}
+	want, err := Throw(seed, roll.Key(), len(roll.Dice), roll.Sides)
+	if err != nil {
+		return err
+	}
+	for i := range want {
+		if roll.Dice[i] != want[i] {
+			return fmt.Errorf("%s: recorded %v, seed gives %v", roll.Key(), roll.Dice, want)
+		}
+	}
+	return nil
+}
+
+// Gaps checks that the rolls in each phase and context are numbered
+// from zero, in order, with no index skipped or repeated. It returns an
+// error for each roll that is out of sequence, since a missing roll may
+// be one that the referee threw again.
+func Gaps(rolls []*model.ROLL) (errs []error) {
+	next := make(map[string]int)
+	for _, roll := range rolls {
+		key := group(roll)
+		if roll.Index != next[key] {
+			errs = append(errs, fmt.Errorf("%s: expected index %d", roll.Key(), next[key]))
+		}
+		next[key] = roll.Index + 1
+	}
+	return errs
+}
+
+// group returns the key that groups rolls for indexing.
+func group(roll *model.ROLL) string {
+	return fmt.Sprintf("%d/%d/%s/%s", roll.Turn, roll.Stage, roll.Phase, roll.Context)
+}
@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package dice
+
+import (
+	"github.com/mdhender/tcfna/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestThrow(t *testing.T) {
+	const seed, key = "abcd", "1/1/Combat Phase/axis:12/0"
+	for _, tc := range []struct {
+		sides int
+		want  []int
+	}{
+		{6, []int{3, 1, 2, 5, 2, 4, 2, 2, 6, 3}},
+		{1, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		// about half of the words are at or above the limit for this
+		// many sides, so rejection skips them and the stream is
+		// extended past the first block.
+		{1<<31 + 1, []int{1922434445, 445009802, 1744806492, 1847712489, 1731481163, 1804399006, 1107066434, 1130651538, 1123740485, 1488026555}},
+	} {
+		got, err := Throw(seed, key, len(tc.want), tc.sides)
+		if err != nil {
+			t.Errorf("d%d: unexpected error %v", tc.sides, err)
+		} else if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("d%d: want %v, got %v", tc.sides, tc.want, got)
+		}
+	}
+
+	for _, sides := range []int{0, -6, 1<<32 + 1, 1 << 40} {
+		if _, err := Throw(seed, key, 1, sides); err == nil {
+			t.Errorf("d%d: want error, got none", sides)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	roll := &model.ROLL{Turn: 1, Stage: 1, Phase: "Combat Phase", Context: "axis:12", Sides: 6, Dice: []int{3, 1}}
+	if err := Verify("abcd", roll); err != nil {
+		t.Errorf("verify: unexpected error %v", err)
+	}
+	for _, bad := range []*model.ROLL{
+		{Turn: 1, Stage: 1, Phase: "Combat Phase", Context: "axis:12", Sides: 6, Dice: []int{3, 2}},
+		{Turn: 1, Stage: 1, Phase: "Combat Phase", Context: "axis:12", Sides: 6},
+		{Turn: 1, Stage: 1, Phase: "Combat Phase", Context: "axis:12", Sides: 0, Dice: []int{3, 1}},
+	} {
+		if err := Verify("abcd", bad); err == nil {
+			t.Errorf("verify %v: want error, got none", bad)
+		}
+	}
+}
+
+func TestGaps(t *testing.T) {
+	roll := func(ctx string, index int) *model.ROLL {
+		return &model.ROLL{Turn: 1, Stage: 1, Phase: "Combat Phase", Context: ctx, Index: index}
+	}
+	if errs := Gaps([]*model.ROLL{roll("axis:12", 0), roll("axis:14", 0), roll("axis:12", 1)}); len(errs) != 0 {
+		t.Errorf("gaps: want none, got %v", errs)
+	}
+	if errs := Gaps([]*model.ROLL{roll("axis:12", 0), roll("axis:12", 2)}); len(errs) != 1 {
+		t.Errorf("skipped index: want 1 error, got %v", errs)
+	}
+	if errs := Gaps([]*model.ROLL{roll("axis:12", 1)}); len(errs) != 1 {
+		t.Errorf("missing first index: want 1 error, got %v", errs)
+	}
+	if errs := Gaps([]*model.ROLL{roll("axis:12", 0), roll("axis:12", 0)}); len(errs) != 1 {
+		t.Errorf("repeated index: want 1 error, got %v", errs)
+	}
+}
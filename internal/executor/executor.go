@@ -0,0 +1,449 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package executor applies checked orders to a game.
+//
+// Orders are executed one at a time, in the order they were given, and
+// every order gets a result. An order that fails the checks is rejected
+// without changing the game. An order that is cut short, say by running
+// out of capability points, is partial and keeps whatever it did.
+package executor
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/combat"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"github.com/mdhender/tcfna/internal/orders"
+	"github.com/mdhender/tcfna/internal/rail"
+	"github.com/mdhender/tcfna/internal/route"
+	"github.com/mdhender/tcfna/internal/stacking"
+	"github.com/mdhender/tcfna/internal/turn"
+	"github.com/mdhender/tcfna/internal/zoc"
+	"sort"
+	"strings"
+)
+
+// RULES are the engines that the executor uses to carry out orders.
+type RULES struct {
+	Movement *movement.ENGINE
+	Combat   *combat.ENGINE
+	ZOC      zoc.RULES      // extra cost for enemy zones of control
+	Stacking stacking.RULES // checked after every move and at the end of the phase
+	Fuel     logistics.FUELRULES
+	Trucks   logistics.TRUCKRULES
+	Rail     rail.RULES
+}
+
+// Execute applies the order files to the game for the current phase
+// and returns the results. When both players give orders in the phase,
+// the first player's orders are executed first.
+func Execute(g *model.GAME, rules RULES, files ...*orders.FILE) (*model.RESULTS, error) {
+	step, err := turn.Current(g)
+	if err != nil {
+		return nil, err
+	} else if step.Phase.Player == turn.Automatic {
+		return nil, fmt.Errorf("no orders are allowed in the %s", step.Phase.Name)
+	}
+
+	first := turn.Initiative(g)
+	seen := make(map[model.SIDE]bool)
+	for _, f := range files {
+		if seen[f.Side] {
+			return nil, fmt.Errorf("more than one order file for %s", f.Side)
+		}
+		seen[f.Side] = true
+	}
+	files = append([]*orders.FILE{}, files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Side == first && files[j].Side != first
+	})
+
+	// games without a seed can still execute orders that need no dice
+	roller, _ := dice.New(g)
+
+	results := &model.RESULTS{Turn: g.Turn, Stage: g.Stage, Phase: g.Phase}
+	for _, f := range files {
+		e := &executor{g: g, rules: rules, roller: roller, ledger: logistics.New(g), side: f.Side}
+		checker := orders.NewChecker(g, rules.Movement, f.Side)
+		for _, o := range f.Orders {
+			pos := o.Pos()
+			var r *model.RESULT
+			// check against the game as it stands after the orders before this one
+			if err := checker.Order(o); err != nil {
+				var msgs []string
+				for _, bad := range err.(orders.ERRORS) {
+					msgs = append(msgs, bad.Msg)
+				}
+				r = &model.RESULT{Status: model.Rejected, Reason: strings.Join(msgs, "; ")}
+			} else {
+				r = e.execute(o, fmt.Sprintf("%s:%d", f.Side, pos.Line))
+			}
+			r.Side, r.Line, r.Order = f.Side, pos.Line, pos.Text
+			results.Results = append(results.Results, r)
+		}
+	}
+	results.Violations = stacking.Check(g, rules.Stacking)
+	return results, nil
+}
+
+// executor holds the state needed while executing a file.
+type executor struct {
+	g      *model.GAME
+	rules  RULES
+	roller *dice.ROLLER
+	ledger *logistics.LEDGER
+	side   model.SIDE
+}
+
+// execute carries out a single order. The context identifies the
+// order for any dice that it rolls.
+func (e *executor) execute(o orders.ORDER, ctx string) *model.RESULT {
+	switch o := o.(type) {
+	case *orders.MOVE:
+		return e.stacked(e.move(e.g.Units.ById(o.Unit), o.Path, ctx), o.Unit)
+	case *orders.RETREAT:
+		return e.stacked(e.move(e.g.Units.ById(o.Unit), o.Path, ctx), o.Unit)
+	case *orders.RESERVE:
+		u := e.g.Units.ById(o.Unit)
+		if u.CP != 0 {
+			return rejected("unit %q has already spent capability points this stage", u.Id)
+		}
+		u.Reserve = true
+		return success("%s designated as a reserve", u.Id)
+	case *orders.ORGANIZE:
+		u, parent := e.g.Units.ById(o.Unit), e.g.Units.ById(o.Parent)
+		for p := parent; p != nil; p = e.g.Units.ById(p.Parent) {
+			if p == u {
+				return rejected("unit %q is already above %q", u.Id, parent.Id)
+			}
+		}
+		u.Parent = parent.Id
+		return success("%s now under %s", u.Id, parent.Id)
+	case *orders.ATTACK:
+		return e.combat(o.Target, o.Units, ctx, e.rules.Combat.CloseAssault)
+	case *orders.BARRAGE:
+		return e.combat(o.Target, o.Units, ctx, e.rules.Combat.Barrage)
+	case *orders.RAIL:
+		if o.Unit == "" {
+			return e.railSupply(o, ctx)
+		}
+		return e.stacked(e.railUnit(e.g.Units.ById(o.Unit), o.To), o.Unit)
+	case *orders.BUILD:
+		if o.Railroad {
+			w, err := rail.Start(e.g, e.rules.Rail, e.side, e.g.Map.Lookup(o.Hex), o.Direction)
+			if err != nil {
+				return rejected("%v", err)
+			}
+			return success("started railroad on the %s side of %s, %d game-turns of work needed", w.Direction, w.Hex, e.rules.Rail.Turns)
+		}
+		if d := e.g.DumpAt(o.Hex); d != nil {
+			return rejected("%s already has a %s dump", o.Hex, d.Side)
+		}
+		_, _ = e.ledger.Build(e.side, o.Hex)
+		return success("built a dump in %s", o.Hex)
+	case *orders.LOAD:
+		u := e.g.Units.ById(o.Unit)
+		if d := e.g.DumpAt(u.Hex); d == nil || d.Side != e.side {
+			return rejected("no %s dump in %s", e.side, u.Hex)
+		}
+		space := e.rules.Trucks.Capacity(u) - logistics.Cargo(u)
+		if space <= 0 {
+			return rejected("unit %q can not carry any more supply", u.Id)
+		} else if o.Amount > space {
+			r := e.transfer(model.DumpId(u.Hex), u.Id, o.Supply, space, ctx)
+			if r.Status == model.Success {
+				r.Status, r.Reason = model.Partial, fmt.Sprintf("unit %q could only carry %d more tons", u.Id, space)
+			}
+			return r
+		}
+		return e.transfer(model.DumpId(u.Hex), u.Id, o.Supply, o.Amount, ctx)
+	case *orders.UNLOAD:
+		u := e.g.Units.ById(o.Unit)
+		if _, err := e.ledger.Build(e.side, u.Hex); err != nil {
+			return rejected("%v", err)
+		}
+		return e.transfer(u.Id, model.DumpId(u.Hex), o.Supply, o.Amount, ctx)
+	case *orders.HAUL:
+		return e.haul(e.g.Units.ById(o.Unit), o.To, ctx)
+	case *orders.FLY:
+		if !e.g.Option("air") {
+			return rejected("air missions are not in play")
+		} else if o.Mission != "recon" {
+			return rejected("%q missions are not implemented yet", o.Mission)
+		}
+		var recon []*model.RECON // drop reconnaissance from earlier game-turns
+		for _, r := range e.g.Recon {
+			if r.Turn == e.g.Turn {
+				recon = append(recon, r)
+			}
+		}
+		e.g.Recon = append(recon, &model.RECON{Side: e.side, Turn: e.g.Turn, Hex: o.Target})
+		return success("%s flew reconnaissance over %s", o.Unit, o.Target)
+	}
+	return rejected("%q orders are not implemented yet", o.Verb())
+}
+
+// move moves the unit along the path, spending capability points for
+// each hex entered and for moving into and out of enemy zones of control.
+// Units that burn fuel burn it for every capability point spent, zone of
+// control costs included. Before leaving, a unit tops up the fuel it
+// carries to its capacity, and it draws only on that fuel as it moves;
+// anything left behind in the hex stays there. The move stops short at
+// an enemy occupied hex, when the unit can not pay for the next hex, or
+// when the unit would run out of fuel.
+func (e *executor) move(u *model.UNIT, path []string, ctx string) *model.RESULT {
+	r := &model.RESULT{}
+	enemy := zoc.Compute(e.g, e.side.Enemy())
+	from := e.g.Map.Lookup(u.Hex)
+	spent, burned, entered := 0.0, 0, 0 // CP spent, fuel burned and hexes entered by this order
+	if len(path) != 0 && e.rules.Fuel.Burn(u, 1) != 0 {
+		topped, err := e.ledger.TopUp(u, e.rules.Fuel.Capacity(u), "movement order "+ctx)
+		if err != nil {
+			return rejected("%v", err)
+		} else if topped != 0 {
+			r.Notes = append(r.Notes, fmt.Sprintf("topped up %d fuel in %s", topped, u.Hex))
+		}
+	}
+	for n, label := range path {
+		to := e.g.Map.Lookup(label)
+		if e.enemyIn(to) {
+			r.Reason = fmt.Sprintf("enemy units in %s", to.Label)
+			break
+		}
+		d, ok := e.g.Map.Adjacent(from, to)
+		if !ok {
+			r.Reason = fmt.Sprintf("%s is not adjacent to %s", to.Label, from.Label)
+			break
+		}
+		cost, err := e.rules.Movement.Cost(from, d, u.Class)
+		if err != nil {
+			r.Reason = err.Error()
+			break
+		}
+		extra := enemy.Extra(e.rules.ZOC, from, to)
+		cost += extra
+		if u.CP+cost > float64(u.CPA) {
+			r.Reason = fmt.Sprintf("unit %q needs %g CP to enter %s but has %g left", u.Id, cost, to.Label, float64(u.CPA)-u.CP)
+			break
+		}
+		fuel := e.rules.Fuel.Burn(u, spent+cost) - burned
+		if fuel > 0 {
+			if err := e.ledger.Refuel(u, fuel, "movement order "+ctx); err != nil {
+				r.Reason = fmt.Sprintf("%v to enter %s", err, to.Label)
+				break
+			}
+			burned += fuel
+		}
+		spent, entered = spent+cost, entered+1
+		u.Hex, u.CP = to.Label, u.CP+cost
+		if extra != 0 {
+			r.Notes = append(r.Notes, fmt.Sprintf("entered %s for %g CP, %g of it for enemy zone of control", to.Label, cost, extra))
+		} else {
+			r.Notes = append(r.Notes, fmt.Sprintf("entered %s for %g CP", to.Label, cost))
+		}
+		from = to
+		if n == len(path)-1 {
+			r.Status = model.Success
+			r.Notes = burnedNote(r.Notes, burned)
+			return r
+		}
+	}
+	if entered == 0 {
+		r.Status = model.Rejected
+	} else {
+		r.Status = model.Partial
+		r.Notes = burnedNote(r.Notes, burned)
+	}
+	return r
+}
+
+// burnedNote notes the fuel burned by a move.
+func burnedNote(notes []string, fuel int) []string {
+	if fuel == 0 {
+		return notes
+	}
+	return append(notes, fmt.Sprintf("burned %d fuel", fuel))
+}
+
+// haul moves the truck unit along the cheapest path to the hex, keeping
+// to the roads where it can, then unloads all of its cargo into the
+// side's dump there. A truck that does not reach the hex keeps its cargo.
+func (e *executor) haul(u *model.UNIT, label string, ctx string) *model.RESULT {
+	r := &model.RESULT{Status: model.Success}
+	if u.Hex != label {
+		from, to := e.g.Map.Lookup(u.Hex), e.g.Map.Lookup(label)
+		enemy := zoc.Compute(e.g, e.side.Enemy())
+		cost := enemy.Cost(e.g.Map, e.rules.ZOC, route.PreferRoads(e.g.Map, route.ClassCost(e.rules.Movement, u.Class), e.rules.Trucks.OffRoad))
+		path, err := route.New(e.g.Map, cost, e.rules.Movement.MinCost(u.Class)).Shortest(from, to)
+		if err != nil {
+			return rejected("%v", err)
+		}
+		var labels []string
+		for _, hex := range path.Hexes[1:] {
+			labels = append(labels, hex.Label)
+		}
+		if r = e.stacked(e.move(u, labels, ctx), u.Id); r.Status != model.Success {
+			return r
+		}
+	}
+
+	if _, err := e.ledger.Build(e.side, label); err != nil {
+		r.Status, r.Reason = model.Partial, err.Error()
+		return r
+	}
+	for _, kind := range model.Supplies {
+		if n, _ := e.ledger.Transfer(u.Id, model.DumpId(label), kind, u.Stock[kind], "order "+ctx); n != 0 {
+			r.Notes = append(r.Notes, fmt.Sprintf("delivered %d %s to %s", n, kind, model.DumpId(label)))
+		}
+	}
+	return r
+}
+
+// railUnit moves the unit by rail. The unit may not have moved this
+// stage and spends all of its capability points.
+func (e *executor) railUnit(u *model.UNIT, label string) *model.RESULT {
+	if u.CP != 0 {
+		return rejected("unit %q has already spent capability points this stage", u.Id)
+	}
+	from, to := e.g.Map.Lookup(u.Hex), e.g.Map.Lookup(label)
+	path, err := rail.Route(e.g, e.side, from, to)
+	if err != nil {
+		return rejected("%v", err)
+	}
+	tons, left := e.rules.Rail.Tons(u), rail.Left(e.g, e.rules.Rail, e.side)
+	if tons > left {
+		return rejected("unit %q needs %d tons of rail capacity but %d are left this game-turn", u.Id, tons, left)
+	}
+	u.Hex, u.CP = to.Label, float64(u.CPA)
+	e.g.Player(e.side).RailTons += tons
+	return success("moved by rail from %s to %s, %d hexes, using %d of %d tons of rail capacity", from.Label, to.Label, len(path)-1, tons, left)
+}
+
+// railSupply moves supply by rail from the side's dump in one hex into
+// its dump in another, moving less than ordered if there is not enough
+// supply or rail capacity.
+func (e *executor) railSupply(o *orders.RAIL, ctx string) *model.RESULT {
+	if d := e.g.DumpAt(o.From); d == nil || d.Side != e.side {
+		return rejected("no %s dump in %s", e.side, o.From)
+	}
+	path, err := rail.Route(e.g, e.side, e.g.Map.Lookup(o.From), e.g.Map.Lookup(o.To))
+	if err != nil {
+		return rejected("%v", err)
+	}
+	source, left := model.DumpId(o.From), rail.Left(e.g, e.rules.Rail, e.side)
+	if left == 0 {
+		return rejected("no rail capacity is left this game-turn")
+	} else if e.ledger.Balance(source, o.Supply) == 0 {
+		return rejected("%s has no %s", source, o.Supply)
+	} else if _, err := e.ledger.Build(e.side, o.To); err != nil {
+		return rejected("%v", err)
+	}
+	amount := o.Amount
+	if amount > left {
+		amount = left
+	}
+	moved, err := e.ledger.Transfer(source, model.DumpId(o.To), o.Supply, amount, "rail order "+ctx)
+	if err != nil {
+		return rejected("%v", err)
+	}
+	e.g.Player(e.side).RailTons += moved
+	r := success("moved %d %s by rail from %s to %s, %d hexes, using %d of %d tons of rail capacity", moved, o.Supply, o.From, o.To, len(path)-1, moved, left)
+	if moved < o.Amount && moved == left {
+		r.Status, r.Reason = model.Partial, fmt.Sprintf("only %d tons of rail capacity were left", left)
+	} else if moved < o.Amount {
+		r.Status, r.Reason = model.Partial, fmt.Sprintf("%s only had %d %s", source, moved, o.Supply)
+	}
+	return r
+}
+
+// transfer moves supply between holders, moving what is there
+// if it is less than the amount ordered.
+func (e *executor) transfer(from, to string, kind model.SUPPLY, amount int, ctx string) *model.RESULT {
+	moved, err := e.ledger.Transfer(from, to, kind, amount, "order "+ctx)
+	if err != nil {
+		return rejected("%v", err)
+	} else if moved == 0 {
+		return rejected("%s has no %s", from, kind)
+	}
+	r := success("moved %d %s from %s to %s", moved, kind, from, to)
+	if moved < amount {
+		r.Status, r.Reason = model.Partial, fmt.Sprintf("%s only had %d %s", from, moved, kind)
+	}
+	return r
+}
+
+// stacked notes on the result if the unit ended its move in an
+// over-stacked hex.
+func (e *executor) stacked(r *model.RESULT, id string) *model.RESULT {
+	if hex := e.g.Map.Lookup(e.g.Units.ById(id).Hex); hex != nil && r.Status != model.Rejected {
+		if v := stacking.Hex(e.g, e.rules.Stacking, hex, e.side); v != nil {
+			r.Notes = append(r.Notes, fmt.Sprintf("over-stacked in %s: %s", v.Hex, v.Message))
+		}
+	}
+	return r
+}
+
+// combat resolves an attack on the target hex by the units and applies
+// the result. Earlier orders may have moved or eliminated the units or
+// the defenders, so the combat engine checks them again.
+func (e *executor) combat(label string, ids []string, ctx string, resolve func(model.UNITS, *model.HEX, model.UNITS, *dice.ROLLER, string) (*combat.RESULT, error)) *model.RESULT {
+	if e.roller == nil {
+		return rejected("the game has no seed for the dice")
+	}
+	target := e.g.Map.Lookup(label)
+	var attackers, defenders model.UNITS
+	for _, id := range ids {
+		u := e.g.Units.ById(id)
+		if u.Hex == "" {
+			return rejected("unit %q has been eliminated", id)
+		}
+		attackers = append(attackers, u)
+	}
+	for _, u := range e.g.UnitsAt(target.Label) {
+		if u.Side() == e.side.Enemy() {
+			defenders = append(defenders, u)
+		}
+	}
+	r, err := resolve(attackers, target, defenders, e.roller, ctx)
+	if err != nil {
+		return rejected("%v", err)
+	}
+	e.rules.Combat.Apply(e.g, e.rules.Movement, r)
+	return &model.RESULT{Status: model.Success, Notes: r.Audit()}
+}
+
+// enemyIn returns true if the hex holds enemy units.
+func (e *executor) enemyIn(hex *model.HEX) bool {
+	for _, u := range e.g.UnitsAt(hex.Label) {
+		if u.Side() == e.side.Enemy() {
+			return true
+		}
+	}
+	return false
+}
+
+func success(format string, args ...interface{}) *model.RESULT {
+	return &model.RESULT{Status: model.Success, Notes: []string{fmt.Sprintf(format, args...)}}
+}
+
+func rejected(format string, args ...interface{}) *model.RESULT {
+	return &model.RESULT{Status: model.Rejected, Reason: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,159 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package logistics
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"math"
+)
+
+// FUELRULES controls fuel consumption and evaporation.
+type FUELRULES struct {
+	// Classes lists the movement classes that burn fuel.
+	Classes map[model.CLASS]bool
+	// Rate is the fuel a unit of each type burns per capability point
+	// spent moving. Types that are missing burn nothing.
+	Rate map[model.UNITTYPE]float64
+	// Jerrican and Flimsy are the percent of the fuel held by a dump or
+	// truck unit that evaporates each operations stage. Axis fuel is in
+	// jerricans. Commonwealth fuel is in flimsies. Fuel only evaporates
+	// when the "flimsies" option is in play.
+	Jerrican int
+	Flimsy   int
+}
+
+// DefaultFuel is the standard fuel rules.
+var DefaultFuel = FUELRULES{
+	Classes: map[model.CLASS]bool{
+		model.ClassMotorized: true,
+		model.ClassTrack:     true,
+	},
+	Rate: map[model.UNITTYPE]float64{
+		model.Infantry:     0.5,
+		model.Armor:        1,
+		model.Recon:        0.5,
+		model.Artillery:    0.5,
+		model.AntiTank:     0.5,
+		model.AntiAircraft: 0.5,
+		model.Engineer:     0.5,
+		model.Headquarters: 0.25,
+		model.Truck:        0.5,
+	},
+	Jerrican: 1,
+	Flimsy:   7,
+}
+
+// Burn returns the fuel a unit burns to spend the capability points moving.
+// A truck unit burns the fuel in its own tanks, never its cargo.
+func (r FUELRULES) Burn(u *model.UNIT, cp float64) int {
+	if !r.Classes[u.Class] {
+		return 0
+	}
+	return int(math.Ceil(cp * r.Rate[u.Type]))
+}
+
+// Capacity returns the most fuel a unit can carry for its own use,
+// which is enough to spend its whole capability point allowance.
+func (r FUELRULES) Capacity(u *model.UNIT) int {
+	return r.Burn(u, float64(u.CPA))
+}
+
+// Tank returns the ledger id of the fuel the unit burns: the unit's own
+// stock, or the tanks of a truck unit.
+func Tank(u *model.UNIT) string {
+	if u.Type == model.Truck {
+		return model.TankId(u.Id)
+	}
+	return u.Id
+}
+
+// TopUp fills the unit's fuel up to the capacity before it leaves its
+// hex. A truck unit fills its tanks from the side's dump in the hex.
+// Other units draw from the cargo of the truck units attached to them
+// in the hex. It returns the amount taken.
+func (l *LEDGER) TopUp(u *model.UNIT, capacity int, note string) (int, error) {
+	var sources []string
+	if u.Type == model.Truck {
+		if d := l.g.DumpAt(u.Hex); d != nil && d.Side == u.Side() {
+			sources = append(sources, model.DumpId(d.Hex))
+		}
+	} else {
+		for _, t := range l.g.UnitsAt(u.Hex) {
+			if t.Type == model.Truck && t.Parent == u.Id {
+				sources = append(sources, t.Id)
+			}
+		}
+	}
+	topped, tank := 0, Tank(u)
+	for _, id := range sources {
+		need := capacity - l.Balance(tank, model.Fuel)
+		if need <= 0 {
+			break
+		}
+		n, err := l.Transfer(id, tank, model.Fuel, need, note)
+		if err != nil {
+			return topped, err
+		}
+		topped += n
+	}
+	return topped, nil
+}
+
+// Refuel draws the fuel the unit burns from the fuel it carries. It
+// returns an error without drawing anything if there is not enough fuel.
+func (l *LEDGER) Refuel(u *model.UNIT, amount int, note string) error {
+	tank := Tank(u)
+	if have := l.Balance(tank, model.Fuel); have < amount {
+		return fmt.Errorf("unit %q needs %d fuel but has %d", u.Id, amount, have)
+	}
+	_, err := l.Draw(tank, model.Fuel, amount, note)
+	return err
+}
+
+// EvaporateFuel removes the fuel lost from dumps and truck units
+// during the operations stage. It returns a result for each loss.
+func (l *LEDGER) EvaporateFuel(rules FUELRULES) (results []*model.RESULT) {
+	lose := func(side model.SIDE, id string, held int) {
+		percent := rules.Jerrican
+		if side == model.Commonwealth {
+			percent = rules.Flimsy
+		}
+		amount := (held*percent + 50) / 100
+		if amount == 0 {
+			return
+		}
+		lost, _ := l.Evaporate(id, model.Fuel, amount, "fuel evaporation")
+		results = append(results, &model.RESULT{
+			Side:   side,
+			Order:  fmt.Sprintf("fuel evaporation from %s", id),
+			Status: model.Success,
+			Notes:  []string{fmt.Sprintf("lost %d of %d fuel", lost, held)},
+		})
+	}
+	for _, d := range l.g.Dumps {
+		lose(d.Side, model.DumpId(d.Hex), d.Stock[model.Fuel])
+	}
+	for _, u := range l.g.Units {
+		if u.Type == model.Truck && u.Hex != "" {
+			lose(u.Side(), u.Id, u.Stock[model.Fuel])
+		}
+	}
+	return results
+}
@@ -0,0 +1,238 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package logistics keeps the books for fuel, ammunition, stores and water.
+//
+// Supply is held by units and by dumps. A truck unit's stock is its
+// cargo; the fuel it burns is held apart in its own tanks. Every change to a holder's stock
+// goes through the LEDGER, which records an entry in the game so that
+// any balance can be traced back to where the supply came from.
+package logistics
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+	"strings"
+)
+
+// LEDGER changes the stocks held in a game and records every change.
+type LEDGER struct {
+	g *model.GAME
+}
+
+// New returns the ledger for the game.
+func New(g *model.GAME) *LEDGER {
+	return &LEDGER{g: g}
+}
+
+// Build creates a dump for the side in the hex. It returns the dump
+// that is already there if it belongs to the side.
+func (l *LEDGER) Build(side model.SIDE, hex string) (*model.DUMP, error) {
+	if d := l.g.DumpAt(hex); d != nil {
+		if d.Side != side {
+			return nil, fmt.Errorf("%s has a %s dump", hex, d.Side)
+		}
+		return d, nil
+	}
+	d := &model.DUMP{Hex: hex, Side: side, Stock: make(model.STOCK)}
+	l.g.Dumps = append(l.g.Dumps, d)
+	sort.Slice(l.g.Dumps, func(i, j int) bool {
+		return l.g.Dumps[i].Hex < l.g.Dumps[j].Hex
+	})
+	return d, nil
+}
+
+// holder returns the stock and side for a ledger id.
+func (l *LEDGER) holder(id string) (model.STOCK, model.SIDE, error) {
+	if strings.HasPrefix(id, "dump:") {
+		d := l.g.DumpAt(strings.TrimPrefix(id, "dump:"))
+		if d == nil {
+			return nil, model.NoSide, fmt.Errorf("no dump in %s", strings.TrimPrefix(id, "dump:"))
+		}
+		if d.Stock == nil {
+			d.Stock = make(model.STOCK)
+		}
+		return d.Stock, d.Side, nil
+	} else if strings.HasPrefix(id, "tank:") {
+		u := l.g.Units.ById(strings.TrimPrefix(id, "tank:"))
+		if u == nil || u.Type != model.Truck {
+			return nil, model.NoSide, fmt.Errorf("unknown truck unit %q", strings.TrimPrefix(id, "tank:"))
+		}
+		if u.Tank == nil {
+			u.Tank = make(model.STOCK)
+		}
+		return u.Tank, u.Side(), nil
+	}
+	u := l.g.Units.ById(id)
+	if u == nil {
+		return nil, model.NoSide, fmt.Errorf("unknown unit %q", id)
+	}
+	if u.Stock == nil {
+		u.Stock = make(model.STOCK)
+	}
+	return u.Stock, u.Side(), nil
+}
+
+// Balance returns the amount of supply held.
+func (l *LEDGER) Balance(id string, kind model.SUPPLY) int {
+	stock, _, err := l.holder(id)
+	if err != nil {
+		return 0
+	}
+	return stock[kind]
+}
+
+// Receive adds supply that enters the game, such as a convoy unloading.
+func (l *LEDGER) Receive(to string, kind model.SUPPLY, amount int, note string) error {
+	stock, side, err := l.holder(to)
+	if err != nil {
+		return err
+	} else if strings.HasPrefix(to, "tank:") && kind != model.Fuel {
+		return fmt.Errorf("%s only holds fuel", to)
+	} else if amount < 0 {
+		return fmt.Errorf("amount must not be negative")
+	}
+	stock[kind] += amount
+	l.record(side, model.EntryReceive, kind, amount, "", to, note)
+	return nil
+}
+
+// Draw uses up supply. It takes what is there, up to the amount, and
+// returns the amount taken.
+func (l *LEDGER) Draw(from string, kind model.SUPPLY, amount int, note string) (int, error) {
+	return l.remove(model.EntryDraw, from, kind, amount, note)
+}
+
+// Evaporate removes supply lost to evaporation. It takes what is there,
+// up to the amount, and returns the amount lost.
+func (l *LEDGER) Evaporate(from string, kind model.SUPPLY, amount int, note string) (int, error) {
+	return l.remove(model.EntryEvaporation, from, kind, amount, note)
+}
+
+func (l *LEDGER) remove(entry, from string, kind model.SUPPLY, amount int, note string) (int, error) {
+	stock, side, err := l.holder(from)
+	if err != nil {
+		return 0, err
+	} else if amount < 0 {
+		return 0, fmt.Errorf("amount must not be negative")
+	}
+	if amount > stock[kind] {
+		amount = stock[kind]
+	}
+	if amount == 0 {
+		return 0, nil
+	}
+	stock[kind] -= amount
+	l.record(side, entry, kind, amount, from, "", note)
+	return amount, nil
+}
+
+// Transfer moves supply between holders of the same side. It moves what
+// is there, up to the amount, and returns the amount moved.
+func (l *LEDGER) Transfer(from, to string, kind model.SUPPLY, amount int, note string) (int, error) {
+	source, side, err := l.holder(from)
+	if err != nil {
+		return 0, err
+	}
+	dest, destSide, err := l.holder(to)
+	if err != nil {
+		return 0, err
+	} else if side != destSide {
+		return 0, fmt.Errorf("%s and %s are not on the same side", from, to)
+	} else if strings.HasPrefix(to, "tank:") && kind != model.Fuel {
+		return 0, fmt.Errorf("%s only holds fuel", to)
+	} else if amount < 0 {
+		return 0, fmt.Errorf("amount must not be negative")
+	}
+	if amount > source[kind] {
+		amount = source[kind]
+	}
+	if amount == 0 {
+		return 0, nil
+	}
+	source[kind] -= amount
+	dest[kind] += amount
+	l.record(side, model.EntryTransfer, kind, amount, from, to, note)
+	return amount, nil
+}
+
+func (l *LEDGER) record(side model.SIDE, kind string, supply model.SUPPLY, amount int, from, to, note string) {
+	l.g.Ledger = append(l.g.Ledger, &model.ENTRY{
+		Turn:   l.g.Turn,
+		Stage:  l.g.Stage,
+		Phase:  l.g.Phase,
+		Side:   side,
+		Kind:   kind,
+		Supply: supply,
+		Amount: amount,
+		From:   from,
+		To:     to,
+		Note:   note,
+	})
+}
+
+// BALANCE is the stock held by one unit or dump.
+type BALANCE struct {
+	Id    string // ledger id of the holder
+	Hex   string
+	Stock model.STOCK
+}
+
+// Fuel returns the fuel held.
+func (b BALANCE) Fuel() int { return b.Stock[model.Fuel] }
+
+// Ammo returns the ammunition held.
+func (b BALANCE) Ammo() int { return b.Stock[model.Ammo] }
+
+// Stores returns the stores held.
+func (b BALANCE) Stores() int { return b.Stock[model.Stores] }
+
+// Water returns the water held.
+func (b BALANCE) Water() int { return b.Stock[model.Water] }
+
+// Balances returns the stock held by the side's dumps, then by its
+// units, with the fuel in a truck unit's own tanks listed after its
+// cargo. Every dump is listed, even an empty one, since it is still on
+// the map. Units and tanks with nothing are left out.
+func Balances(g *model.GAME, side model.SIDE) (balances []BALANCE) {
+	for _, d := range g.Dumps {
+		if d.Side == side {
+			balances = append(balances, BALANCE{Id: model.DumpId(d.Hex), Hex: d.Hex, Stock: d.Stock})
+		}
+	}
+	for _, u := range g.Units {
+		if u.Side() == side && !empty(u.Stock) {
+			balances = append(balances, BALANCE{Id: u.Id, Hex: u.Hex, Stock: u.Stock})
+		}
+		if u.Side() == side && !empty(u.Tank) {
+			balances = append(balances, BALANCE{Id: model.TankId(u.Id), Hex: u.Hex, Stock: u.Tank})
+		}
+	}
+	return balances
+}
+
+// empty returns true if the stock holds nothing.
+func empty(stock model.STOCK) bool {
+	for _, amount := range stock {
+		if amount != 0 {
+			return false
+		}
+	}
+	return true
+}
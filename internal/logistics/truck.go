@@ -0,0 +1,117 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package logistics
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/model"
+)
+
+// TRUCKRULES controls how much truck units carry and how often they
+// break down.
+type TRUCKRULES struct {
+	// Tons is the supply carried per TOE point.
+	Tons int
+	// OffRoad is added to the cost of each move that is not along a
+	// road or track when finding a path for a truck unit. It changes
+	// which path is chosen, not what the move costs.
+	OffRoad float64
+	// Breakdown is the two dice total at which a truck unit that moved
+	// breaks down. Wear is the capability points spent for each point
+	// added to the roll.
+	Breakdown int
+	Wear      float64
+}
+
+// DefaultTrucks is the standard truck rules.
+var DefaultTrucks = TRUCKRULES{
+	Tons:      5,
+	OffRoad:   2,
+	Breakdown: 11,
+	Wear:      10,
+}
+
+// Capacity returns the tons of supply the truck unit can carry.
+func (r TRUCKRULES) Capacity(u *model.UNIT) int {
+	if u.Type != model.Truck {
+		return 0
+	}
+	return u.TOE * r.Tons
+}
+
+// Cargo returns the tons of supply the unit is carrying.
+func Cargo(u *model.UNIT) (tons int) {
+	for _, amount := range u.Stock {
+		tons += amount
+	}
+	return tons
+}
+
+// Lose removes supply that is destroyed. It takes what is there, up to
+// the amount, and returns the amount lost.
+func (l *LEDGER) Lose(from string, kind model.SUPPLY, amount int, note string) (int, error) {
+	return l.remove(model.EntryLoss, from, kind, amount, note)
+}
+
+// Breakdown rolls for every truck unit of the side that spent capability
+// points this stage. A unit that breaks down loses a TOE point and the
+// cargo it can no longer carry; one that loses its last TOE point is
+// eliminated with all of its cargo. It returns a result for each roll.
+func (l *LEDGER) Breakdown(rules TRUCKRULES, roller *dice.ROLLER, side model.SIDE) (results []*model.RESULT, err error) {
+	for _, u := range l.g.Units {
+		if u.Type != model.Truck || u.Side() != side || u.Hex == "" || u.CP == 0 {
+			continue
+		}
+		roll, err := roller.Roll(side, "breakdown:"+u.Id, fmt.Sprintf("breakdown of %s", u.Id), 2, 6)
+		if err != nil {
+			return results, err
+		}
+		wear := 0
+		if rules.Wear > 0 {
+			wear = int(u.CP / rules.Wear)
+		}
+		r := &model.RESULT{Side: side, Order: fmt.Sprintf("breakdown of %s", u.Id), Status: model.Success}
+		r.Notes = append(r.Notes, fmt.Sprintf("rolled %d (%s), %+d for %g CP spent, breaks down on %d", roll.Total(), roll.Key(), wear, u.CP, rules.Breakdown))
+		results = append(results, r)
+		if roll.Total()+wear < rules.Breakdown {
+			continue
+		}
+
+		u.TOE--
+		r.Status, r.Reason = model.Partial, "broke down"
+		r.Notes = append(r.Notes, "lost 1 TOE")
+		over := Cargo(u) - rules.Capacity(u)
+		for _, kind := range model.Supplies {
+			if over <= 0 {
+				break
+			}
+			lost, _ := l.Lose(u.Id, kind, over, "breakdown")
+			if lost != 0 {
+				r.Notes = append(r.Notes, fmt.Sprintf("lost %d %s", lost, kind))
+			}
+			over -= lost
+		}
+		if u.TOE <= 0 {
+			u.TOE, u.Hex = 0, ""
+			r.Notes = append(r.Notes, "eliminated")
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,146 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package logistics
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"math"
+)
+
+// WATERRULES controls water consumption and evaporation.
+type WATERRULES struct {
+	// Rate is the water a unit of each type drinks per TOE point
+	// each operations stage. Types that are missing drink nothing.
+	Rate map[model.UNITTYPE]float64
+	// Percent adjusts the rate by nationality. Nationalities that
+	// are missing drink the full rate.
+	Percent map[model.NATIONALITY]int
+	// Dump and Truck are the percent of the water held by a dump or
+	// truck unit that evaporates each operations stage.
+	Dump  int
+	Truck int
+}
+
+// DefaultWater is the standard water rules. Italian units use extra
+// water to cook their pasta.
+var DefaultWater = WATERRULES{
+	Rate: map[model.UNITTYPE]float64{
+		model.Infantry:     0.5,
+		model.Armor:        0.25,
+		model.Recon:        0.25,
+		model.Artillery:    0.25,
+		model.AntiTank:     0.25,
+		model.AntiAircraft: 0.25,
+		model.Engineer:     0.5,
+		model.Headquarters: 0.25,
+		model.Truck:        0.25,
+	},
+	Percent: map[model.NATIONALITY]int{
+		model.Italian: 125,
+	},
+	Dump:  5,
+	Truck: 10,
+}
+
+// Need returns the water the unit drinks in an operations stage.
+func (r WATERRULES) Need(u *model.UNIT) int {
+	percent, ok := r.Percent[u.Nationality]
+	if !ok {
+		percent = 100
+	}
+	return int(math.Ceil(float64(u.TOE) * r.Rate[u.Type] * float64(percent) / 100))
+}
+
+// Drink charges every unit on the map its water for the operations stage.
+// A unit drinks from its own stock first, then from a friendly dump in
+// its hex. Units in an oasis or village/bir hex drink from the wells and
+// use none of their stock. A unit that goes short loses a cohesion level;
+// one that goes short for a second stage in a row also loses a TOE point.
+// It returns a result for each unit that drank or went short.
+func (l *LEDGER) Drink(rules WATERRULES) (results []*model.RESULT) {
+	for _, u := range l.g.Units {
+		hex := l.g.Map.Lookup(u.Hex)
+		need := rules.Need(u)
+		if hex == nil || need == 0 {
+			continue
+		}
+		r := &model.RESULT{Side: u.Side(), Order: fmt.Sprintf("water for %s", u.Id), Status: model.Success}
+		results = append(results, r)
+
+		if hex.Features.Has(model.Oasis) || hex.Features.Has(model.VillageBir) {
+			u.Thirst = 0
+			r.Notes = append(r.Notes, fmt.Sprintf("drank %d from the wells in %s", need, hex.Label))
+			continue
+		}
+
+		drank, _ := l.Draw(u.Id, model.Water, need, "water consumption")
+		if drank < need {
+			if d := l.g.DumpAt(hex.Label); d != nil && d.Side == u.Side() {
+				n, _ := l.Draw(model.DumpId(hex.Label), model.Water, need-drank, fmt.Sprintf("water consumption by %s", u.Id))
+				drank += n
+			}
+		}
+		if drank != 0 {
+			r.Notes = append(r.Notes, fmt.Sprintf("drank %d of %d", drank, need))
+		}
+		if drank == need {
+			u.Thirst = 0
+			continue
+		}
+
+		u.Thirst++
+		u.Cohesion--
+		r.Status = model.Partial
+		r.Reason = fmt.Sprintf("short %d water", need-drank)
+		r.Notes = append(r.Notes, "lost 1 cohesion for lack of water")
+		if u.Thirst > 1 && u.TOE > 0 {
+			u.TOE--
+			r.Notes = append(r.Notes, fmt.Sprintf("lost 1 TOE after %d stages without water", u.Thirst))
+		}
+	}
+	return results
+}
+
+// EvaporateWater removes the water lost from dumps and truck units
+// during the operations stage. It returns a result for each loss.
+func (l *LEDGER) EvaporateWater(rules WATERRULES) (results []*model.RESULT) {
+	lose := func(side model.SIDE, id string, held, percent int) {
+		amount := (held*percent + 50) / 100
+		if amount == 0 {
+			return
+		}
+		lost, _ := l.Evaporate(id, model.Water, amount, "water evaporation")
+		results = append(results, &model.RESULT{
+			Side:   side,
+			Order:  fmt.Sprintf("water evaporation from %s", id),
+			Status: model.Success,
+			Notes:  []string{fmt.Sprintf("lost %d of %d water", lost, held)},
+		})
+	}
+	for _, d := range l.g.Dumps {
+		lose(d.Side, model.DumpId(d.Hex), d.Stock[model.Water], rules.Dump)
+	}
+	for _, u := range l.g.Units {
+		if u.Type == model.Truck && u.Hex != "" {
+			lose(u.Side(), u.Id, u.Stock[model.Water], rules.Truck)
+		}
+	}
+	return results
+}
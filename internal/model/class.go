@@ -0,0 +1,57 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+// CLASS is the way a unit moves, which determines what it pays to enter a hex.
+type CLASS int
+
+const (
+	NoClass CLASS = iota
+	ClassMotorized
+	ClassNonMotorized
+	ClassTrack
+	ClassFoot
+)
+
+var classNames = []string{"", "motorized", "non-motorized", "track", "foot"}
+
+// Classes is every movement class.
+var Classes = []CLASS{ClassMotorized, ClassNonMotorized, ClassTrack, ClassFoot}
+
+// ParseClass converts a class name. Names are not case-sensitive.
+func ParseClass(s string) (CLASS, error) {
+	n, err := parseName("class", classNames, s)
+	return CLASS(n), err
+}
+
+// String implements fmt.Stringer
+func (c CLASS) String() string {
+	return nameOf("CLASS", classNames, int(c))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (c CLASS) MarshalText() ([]byte, error) {
+	return marshalName("class", classNames, int(c))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *CLASS) UnmarshalText(b []byte) (err error) {
+	*c, err = ParseClass(string(b))
+	return err
+}
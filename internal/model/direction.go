@@ -0,0 +1,123 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DIRECTION is one of the six sides of a hex.
+// The order matches the Sides struct on HEX.
+type DIRECTION int
+
+const (
+	DirNE DIRECTION = iota
+	DirE
+	DirSE
+	DirSW
+	DirW
+	DirNW
+)
+
+// Directions is every direction, clockwise from north-east.
+var Directions = [6]DIRECTION{DirNE, DirE, DirSE, DirSW, DirW, DirNW}
+
+// Opposite returns the direction as seen from the neighboring hex.
+// The E side of one hex is the W side of its neighbor to the east.
+func (d DIRECTION) Opposite() DIRECTION {
+	return (d + 3) % 6
+}
+
+// String implements fmt.Stringer
+func (d DIRECTION) String() string {
+	switch d {
+	case DirNE:
+		return "NE"
+	case DirE:
+		return "E"
+	case DirSE:
+		return "SE"
+	case DirSW:
+		return "SW"
+	case DirW:
+		return "W"
+	case DirNW:
+		return "NW"
+	}
+	return fmt.Sprintf("DIRECTION(%d)", int(d))
+}
+
+// ParseDirection converts a direction name. Names are not case-sensitive.
+func ParseDirection(s string) (DIRECTION, error) {
+	for _, d := range Directions {
+		if strings.EqualFold(strings.TrimSpace(s), d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown direction %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d DIRECTION) MarshalText() ([]byte, error) {
+	if !(DirNE <= d && d <= DirNW) {
+		return nil, fmt.Errorf("invalid direction %d", int(d))
+	}
+	return []byte(strings.ToLower(d.String())), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *DIRECTION) UnmarshalText(b []byte) (err error) {
+	*d, err = ParseDirection(string(b))
+	return err
+}
+
+// delta returns the change in row and column to move one hex in the direction.
+// Rows increase to the north and even rows are shifted half a hex to the east.
+// This is the same convention used when rendering the board.
+func (d DIRECTION) delta(row int) (dRow, dCol int) {
+	even := row%2 == 0
+	switch d {
+	case DirNE:
+		if even {
+			return 1, 1
+		}
+		return 1, 0
+	case DirE:
+		return 0, 1
+	case DirSE:
+		if even {
+			return -1, 1
+		}
+		return -1, 0
+	case DirSW:
+		if even {
+			return -1, 0
+		}
+		return -1, -1
+	case DirW:
+		return 0, -1
+	case DirNW:
+		if even {
+			return 1, 0
+		}
+		return 1, -1
+	}
+	return 0, 0
+}
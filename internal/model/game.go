@@ -0,0 +1,160 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+// GAME is the state of a game in progress.
+// The board is not saved with the game; the game records the name of
+// the board file and the board is loaded along with the game.
+type GAME struct {
+	Name           string           `json:"name,omitempty"`
+	Board          string           `json:"board"` // name of the board file, relative to the game file
+	Map            *MAP             `json:"-"`
+	Turn           int              `json:"turn"`  // game-turn, starting at 1
+	Stage          int              `json:"stage"` // operations stage, 1 to 3, or 0 between stages
+	Phase          string           `json:"phase,omitempty"`
+	Initiative     SIDE             `json:"initiative,omitempty"` // first player in the current stage
+	LastTurn       int              `json:"last-turn,omitempty"`  // game ends after this game-turn
+	Weather        WEATHER          `json:"weather"`
+	Options        map[string]bool  `json:"options,omitempty"` // optional rules in play
+	Players        []*PLAYER        `json:"players"`
+	Units          UNITS            `json:"units"`
+	Reinforcements []*REINFORCEMENT `json:"reinforcements,omitempty"` // units that have not arrived yet
+	Victory        []*VICTORY       `json:"victory,omitempty"`
+	Recon          []*RECON         `json:"recon,omitempty"`     // air reconnaissance flown this game-turn
+	Seed           string           `json:"seed,omitempty"`      // secret that every die roll is derived from
+	SeedHash       string           `json:"seed-hash,omitempty"` // published hash of the seed
+	Rolls          []*ROLL          `json:"rolls,omitempty"`     // every die roll made in the game
+	Dumps          []*DUMP          `json:"dumps,omitempty"`
+	Ledger         []*ENTRY         `json:"ledger,omitempty"` // every movement of supply in the game
+	Rail           []*RAILWORK      `json:"rail,omitempty"`   // railroad construction, finished or not
+}
+
+// PLAYER is the per-player data for a game.
+type PLAYER struct {
+	Side     SIDE     `json:"side"`
+	Name     string   `json:"name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Sources  []string `json:"sources,omitempty"`   // labels of the hexes the side traces supply from
+	Railhead string   `json:"railhead,omitempty"`  // label of the hex the side's trains run from
+	RailTons int      `json:"rail-tons,omitempty"` // tons the side has moved by rail this game-turn
+}
+
+// REINFORCEMENT is a unit that enters the map during the game.
+type REINFORCEMENT struct {
+	Turn int    `json:"turn"` // game-turn the unit arrives
+	Unit string `json:"unit"` // id of the unit
+	Hex  string `json:"hex"`  // label of the hex the unit arrives in
+}
+
+// RECON is a hex that one side has flown air reconnaissance over.
+// It shows the side what is in and around the hex for the rest of the game-turn.
+type RECON struct {
+	Side SIDE   `json:"side"`
+	Turn int    `json:"turn"`
+	Hex  string `json:"hex"`
+}
+
+// RAILWORK is construction that turns a hexside of unfinished railroad
+// into railroad. Finished work is kept because the board is not saved
+// with the game; the finished hexsides are changed when the game is loaded.
+type RAILWORK struct {
+	Side      SIDE      `json:"side"`
+	Hex       string    `json:"hex"`
+	Direction DIRECTION `json:"direction"`
+	Turns     int       `json:"turns"` // game-turns of work done
+	Done      bool      `json:"done,omitempty"`
+}
+
+// VICTORY is a victory condition for one side.
+// The side wins if it controls every hex in Hold at the end of the game.
+type VICTORY struct {
+	Side        SIDE     `json:"side"`
+	Description string   `json:"description,omitempty"`
+	Hold        []string `json:"hold,omitempty"` // labels of hexes the side must control
+}
+
+// Option returns true if the optional rule is in play.
+func (g *GAME) Option(name string) bool {
+	return g.Options[name]
+}
+
+// Player returns the player for the side, or nil if there is no such player.
+func (g *GAME) Player(side SIDE) *PLAYER {
+	for _, p := range g.Players {
+		if p.Side == side {
+			return p
+		}
+	}
+	return nil
+}
+
+// DumpAt returns the dump in the hex with the given label, or nil if there is none.
+func (g *GAME) DumpAt(label string) *DUMP {
+	for _, d := range g.Dumps {
+		if d.Hex == label {
+			return d
+		}
+	}
+	return nil
+}
+
+// UnitsAt returns the units in the hex with the given label.
+func (g *GAME) UnitsAt(label string) (units UNITS) {
+	for _, u := range g.Units {
+		if u.Hex == label {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// WEATHER is the weather for the current game-turn.
+type WEATHER int
+
+const (
+	NoWeather WEATHER = iota
+	Normal
+	Hot
+	Sandstorm
+	Rainstorm
+)
+
+var weatherNames = []string{"", "normal", "hot", "sandstorm", "rainstorm"}
+
+// ParseWeather converts a weather name. Names are not case-sensitive.
+func ParseWeather(s string) (WEATHER, error) {
+	n, err := parseName("weather", weatherNames, s)
+	return WEATHER(n), err
+}
+
+// String implements fmt.Stringer
+func (w WEATHER) String() string {
+	return nameOf("WEATHER", weatherNames, int(w))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (w WEATHER) MarshalText() ([]byte, error) {
+	return marshalName("weather", weatherNames, int(w))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (w *WEATHER) UnmarshalText(b []byte) (err error) {
+	*w, err = ParseWeather(string(b))
+	return err
+}
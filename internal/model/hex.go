@@ -63,15 +63,16 @@ func (h HEXES) Swap(i, j int) {
 
 // HEX is the data for a hex on the map board
 type HEX struct {
-	Id         string `json:"id"`
-	Section    string `json:"section"`
-	Row        int    `json:"row"`
-	Column     int    `json:"column"`
-	Label      string `json:"label,omitempty"`
-	Name       string `json:"Name,omitempty"`
-	Terrain    string `json:"terrain,omitempty"`
-	Habitation string `json:"habitation,omitempty"`
-	Misc       string `json:"misc,omitempty"`
+	Id         string   `json:"id"`
+	Section    string   `json:"section"`
+	Row        int      `json:"row"`
+	Column     int      `json:"column"`
+	Label      string   `json:"label,omitempty"`
+	Name       string   `json:"Name,omitempty"`
+	Terrain    TERRAIN  `json:"terrain,omitempty"`
+	Features   FEATURES `json:"features,omitempty"`
+	Habitation string   `json:"habitation,omitempty"`
+	Misc       string   `json:"misc,omitempty"`
 	Sides      struct {
 		NE HEXSIDE `json:"ne,omitempty"`
 		E  HEXSIDE `json:"e,omitempty"`
@@ -82,8 +83,54 @@ type HEX struct {
 	} `json:"sides,omitempty"`
 }
 
+// HEXSIDE is the data for one side of a hex.
 type HEXSIDE struct {
-	Elevation string `json:"elevation,omitempty"`
-	Trans     string `json:"trans,omitempty"`
-	Water     string `json:"water,omitempty"`
+	Elevation ELEVATION `json:"elevation,omitempty"`
+	Trans     TRANS     `json:"trans,omitempty"`
+	Water     WATER     `json:"water,omitempty"`
+}
+
+// Side returns the hexside in the given direction.
+// The pointer lets callers update the hexside in place.
+func (h *HEX) Side(d DIRECTION) *HEXSIDE {
+	switch d {
+	case DirNE:
+		return &h.Sides.NE
+	case DirE:
+		return &h.Sides.E
+	case DirSE:
+		return &h.Sides.SE
+	case DirSW:
+		return &h.Sides.SW
+	case DirW:
+		return &h.Sides.W
+	case DirNW:
+		return &h.Sides.NW
+	}
+	return nil
+}
+
+// Distance returns the number of hexes between h and o.
+func (h *HEX) Distance(o *HEX) int {
+	// convert the offset coordinates to cube coordinates.
+	// even rows are shifted half a hex to the east.
+	hq, oq := h.Column-(h.Row+(h.Row&1))/2, o.Column-(o.Row+(o.Row&1))/2
+	dq, dr := hq-oq, h.Row-o.Row
+	ds := -dq - dr
+	if dq < 0 {
+		dq = -dq
+	}
+	if dr < 0 {
+		dr = -dr
+	}
+	if ds < 0 {
+		ds = -ds
+	}
+	if dq < dr {
+		dq = dr
+	}
+	if dq < ds {
+		dq = ds
+	}
+	return dq
 }
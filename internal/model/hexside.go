@@ -0,0 +1,315 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ELEVATION is the change in elevation when crossing a hexside,
+// as seen from the hex that records it.
+type ELEVATION int
+
+const (
+	NoElevation ELEVATION = iota
+	DownEscarpment
+	DownSlope
+	Ridge
+	UpEscarpment
+	UpSlope
+)
+
+// TRANS is the road, track, or railroad that crosses a hexside.
+type TRANS int
+
+const (
+	NoTrans TRANS = iota
+	Track
+	Road
+	Railroad
+	UnfinishedRoad
+	UnfinishedRailroad
+	RoadAndRailroad
+)
+
+// WATER is the water feature (or border) that runs along a hexside.
+type WATER int
+
+const (
+	NoWater WATER = iota
+	Wadi
+	SeaHexside
+	River
+	Nile
+	Border
+)
+
+// the codes are taken from the sub-header of Michael Miller's hex database.
+// the position in the slice is the numeric code used in the data.
+var (
+	elevationCodes = []string{"", "1-DnEsc", "2-DnSlp", "3-Ridge", "4-UpEsc", "5-UpSlp"}
+	transCodes     = []string{"", "1-Track", "2-Road", "3-RR", "4-UnfRd", "5-UnfRR", "6-Rd&RR"}
+	waterCodes     = []string{"", "1-Wadi", "2-SeaHS", "3-River", "4-Nile", "5-Border"}
+)
+
+// ParseElevation converts an elevation code from the hex database.
+// It accepts the bare number ("4"), the legend ("UpEsc"), or both ("4-UpEsc").
+func ParseElevation(s string) (ELEVATION, error) {
+	n, err := parseCode("elevation", elevationCodes, s)
+	return ELEVATION(n), err
+}
+
+// ParseTrans converts a transport code from the hex database.
+// It accepts the bare number ("6"), the legend ("Rd&RR"), or both ("6-Rd&RR").
+func ParseTrans(s string) (TRANS, error) {
+	n, err := parseCode("trans", transCodes, s)
+	return TRANS(n), err
+}
+
+// ParseWater converts a water code from the hex database.
+// It accepts the bare number ("3"), the legend ("River"), or both ("3-River").
+func ParseWater(s string) (WATER, error) {
+	n, err := parseCode("water", waterCodes, s)
+	return WATER(n), err
+}
+
+// parseCode returns the index of s in codes.
+// An empty string is always index zero.
+func parseCode(kind string, codes []string, s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	for n, code := range codes[1:] {
+		number, legend := code[:strings.Index(code, "-")], code[strings.Index(code, "-")+1:]
+		if s == code || s == number || strings.EqualFold(s, legend) {
+			return n + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s code %q", kind, s)
+}
+
+// Mirror returns the elevation as seen from the other side of the hexside.
+// An up escarpment on one side is a down escarpment on the other.
+func (e ELEVATION) Mirror() ELEVATION {
+	switch e {
+	case DownEscarpment:
+		return UpEscarpment
+	case DownSlope:
+		return UpSlope
+	case UpEscarpment:
+		return DownEscarpment
+	case UpSlope:
+		return DownSlope
+	}
+	return e
+}
+
+// String implements fmt.Stringer
+func (e ELEVATION) String() string {
+	if 0 <= e && int(e) < len(elevationCodes) {
+		return elevationCodes[e]
+	}
+	return fmt.Sprintf("ELEVATION(%d)", int(e))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (e ELEVATION) MarshalText() ([]byte, error) {
+	if !(0 <= e && int(e) < len(elevationCodes)) {
+		return nil, fmt.Errorf("invalid elevation %d", int(e))
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (e *ELEVATION) UnmarshalText(b []byte) (err error) {
+	*e, err = ParseElevation(string(b))
+	return err
+}
+
+// HasRoad is true if the hexside has a finished road.
+func (t TRANS) HasRoad() bool {
+	return t == Road || t == RoadAndRailroad
+}
+
+// HasRailroad is true if the hexside has a finished railroad.
+func (t TRANS) HasRailroad() bool {
+	return t == Railroad || t == RoadAndRailroad
+}
+
+// String implements fmt.Stringer
+func (t TRANS) String() string {
+	if 0 <= t && int(t) < len(transCodes) {
+		return transCodes[t]
+	}
+	return fmt.Sprintf("TRANS(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t TRANS) MarshalText() ([]byte, error) {
+	if !(0 <= t && int(t) < len(transCodes)) {
+		return nil, fmt.Errorf("invalid trans %d", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *TRANS) UnmarshalText(b []byte) (err error) {
+	*t, err = ParseTrans(string(b))
+	return err
+}
+
+// String implements fmt.Stringer
+func (w WATER) String() string {
+	if 0 <= w && int(w) < len(waterCodes) {
+		return waterCodes[w]
+	}
+	return fmt.Sprintf("WATER(%d)", int(w))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (w WATER) MarshalText() ([]byte, error) {
+	if !(0 <= w && int(w) < len(waterCodes)) {
+		return nil, fmt.Errorf("invalid water %d", int(w))
+	}
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (w *WATER) UnmarshalText(b []byte) (err error) {
+	*w, err = ParseWater(string(b))
+	return err
+}
+
+// MISMATCH is a shared hexside where the two hexes disagree.
+type MISMATCH struct {
+	Hex      *HEX
+	Side     DIRECTION
+	Neighbor *HEX
+	Field    string // elevation, trans, or water
+	Value    string // value recorded by Hex
+	Other    string // value recorded by Neighbor
+	Want     string // value Neighbor should have to agree with Hex
+}
+
+// String implements fmt.Stringer
+func (m MISMATCH) String() string {
+	return fmt.Sprintf("%s %-2s %-9s %-9q / %s %-2s %-9q want %q", m.Hex.Label, m.Side, m.Field, m.Value, m.Neighbor.Label, m.Side.Opposite(), m.Other, m.Want)
+}
+
+// CheckHexsides walks every shared hexside on the board and returns
+// the ones where the two hexes record different values.
+// Transport and water must be identical on both sides.
+// Elevation must be mirrored; an up escarpment on one side is a down
+// escarpment on the other.
+func (m *MAP) CheckHexsides() (mismatches []MISMATCH) {
+	for _, hex := range m.Sorted {
+		// every hexside is the NE, E, or SE side of exactly one hex,
+		// so checking those three visits each shared side once.
+		for _, d := range []DIRECTION{DirNE, DirE, DirSE} {
+			neighbor := m.Neighbor(hex, d)
+			if neighbor == nil {
+				continue
+			}
+			hs, ns := hex.Side(d), neighbor.Side(d.Opposite())
+			if hs.Elevation.Mirror() != ns.Elevation {
+				mismatches = append(mismatches, MISMATCH{Hex: hex, Side: d, Neighbor: neighbor, Field: "elevation", Value: hs.Elevation.String(), Other: ns.Elevation.String(), Want: hs.Elevation.Mirror().String()})
+			}
+			if hs.Trans != ns.Trans {
+				mismatches = append(mismatches, MISMATCH{Hex: hex, Side: d, Neighbor: neighbor, Field: "trans", Value: hs.Trans.String(), Other: ns.Trans.String(), Want: hs.Trans.String()})
+			}
+			if hs.Water != ns.Water {
+				mismatches = append(mismatches, MISMATCH{Hex: hex, Side: d, Neighbor: neighbor, Field: "water", Value: hs.Water.String(), Other: ns.Water.String(), Want: hs.Water.String()})
+			}
+		}
+	}
+	return mismatches
+}
+
+// Reconcile fixes mismatched hexsides where one hex is blank and the
+// other is not by copying (and mirroring, for elevation) the value that
+// was recorded. Sides where both hexes record conflicting values are
+// left alone and returned to the caller.
+func (m *MAP) Reconcile() (fixed int, unresolved []MISMATCH) {
+	for _, mm := range m.CheckHexsides() {
+		hs, ns := mm.Hex.Side(mm.Side), mm.Neighbor.Side(mm.Side.Opposite())
+		switch mm.Field {
+		case "elevation":
+			if hs.Elevation == NoElevation {
+				hs.Elevation = ns.Elevation.Mirror()
+			} else if ns.Elevation == NoElevation {
+				ns.Elevation = hs.Elevation.Mirror()
+			} else {
+				unresolved = append(unresolved, mm)
+				continue
+			}
+		case "trans":
+			if hs.Trans == NoTrans {
+				hs.Trans = ns.Trans
+			} else if ns.Trans == NoTrans {
+				ns.Trans = hs.Trans
+			} else {
+				unresolved = append(unresolved, mm)
+				continue
+			}
+		case "water":
+			if hs.Water == NoWater {
+				hs.Water = ns.Water
+			} else if ns.Water == NoWater {
+				ns.Water = hs.Water
+			} else {
+				unresolved = append(unresolved, mm)
+				continue
+			}
+		}
+		fixed++
+	}
+	return fixed, unresolved
+}
+
+// SetTrans changes the road, track, or railroad on the d side of h.
+// Both hexes' records of the hexside are changed so that they agree.
+func (m *MAP) SetTrans(h *HEX, d DIRECTION, t TRANS) {
+	h.Side(d).Trans = t
+	if neighbor := m.Neighbor(h, d); neighbor != nil {
+		neighbor.Side(d.Opposite()).Trans = t
+	}
+}
+
+// Hexside returns the hexside on the d side of h.
+// Values that h leaves blank are taken from the neighbor's record of
+// the same side, with elevation mirrored so that the result is always
+// as seen when crossing out of h.
+func (m *MAP) Hexside(h *HEX, d DIRECTION) HEXSIDE {
+	hs := *h.Side(d)
+	if neighbor := m.Neighbor(h, d); neighbor != nil {
+		ns := neighbor.Side(d.Opposite())
+		if hs.Elevation == NoElevation {
+			hs.Elevation = ns.Elevation.Mirror()
+		}
+		if hs.Trans == NoTrans {
+			hs.Trans = ns.Trans
+		}
+		if hs.Water == NoWater {
+			hs.Water = ns.Water
+		}
+	}
+	return hs
+}
@@ -18,47 +18,66 @@
 
 package model
 
+import "fmt"
+
 type MAP struct {
 	Hexes  map[string]*HEX `json:"hexes"`
 	Sorted HEXES
+
+	labels map[string]*HEX // index of hexes by label, see Index
+	count  int             // number of hexes when the index was built
+}
+
+// At returns the hex at the given row and column, or nil if there is no such hex.
+func (m *MAP) At(row, col int) *HEX {
+	return m.Hexes[fmt.Sprintf("%02d%03d", row, col)]
+}
+
+// Index builds the index of hexes by label. The loaders call it once
+// the board is read. Lookup calls it again when hexes have been added
+// or removed; call it after changing the label of a hex.
+func (m *MAP) Index() {
+	m.labels = make(map[string]*HEX)
+	for _, hex := range m.Hexes {
+		if hex.Label != "" {
+			m.labels[hex.Label] = hex
+		}
+	}
+	m.count = len(m.Hexes)
+}
+
+// Lookup returns the hex with the given label (for example, "C4708"),
+// or nil if there is no such hex.
+func (m *MAP) Lookup(label string) *HEX {
+	if m.labels == nil || m.count != len(m.Hexes) {
+		m.Index()
+	}
+	return m.labels[label]
+}
+
+// Neighbor returns the hex adjacent to h in the given direction.
+// Because columns are numbered across the entire board, this works
+// across the section boundaries. It returns nil at the edge of the board.
+func (m *MAP) Neighbor(h *HEX, d DIRECTION) *HEX {
+	dRow, dCol := d.delta(h.Row)
+	return m.At(h.Row+dRow, h.Column+dCol)
 }
 
-type TERRAIN struct {
-	Section string `json:"section,omitempty"`
+// Neighbors returns the hexes adjacent to h, indexed by direction.
+// Entries are nil where h is on the edge of the board.
+func (m *MAP) Neighbors(h *HEX) (neighbors [6]*HEX) {
+	for _, d := range Directions {
+		neighbors[d] = m.Neighbor(h, d)
+	}
+	return neighbors
 }
 
-type Terrain struct {
-	Airfield                TERRAIN `json:"airfield,omitempty"`
-	Border                  TERRAIN `json:"border,omitempty"`
-	Clear                   TERRAIN `json:"clear,omitempty"`
-	Coast                   TERRAIN `json:"coast,omitempty"`
-	Delta                   TERRAIN `json:"delta,omitempty"`
-	Desert                  TERRAIN `json:"desert,omitempty"`
-	Escarpment              TERRAIN `json:"escarpment,omitempty"`
-	FlyingBoatAlightingArea TERRAIN `json:"flying boat alighting area,omitempty"`
-	FlyingBoatBasin         TERRAIN `json:"flying boat basin,omitempty"`
-	HeavyVegetation         TERRAIN `json:"heavy vegetation,omitempty"`
-	MajorCity               TERRAIN `json:"major city,omitempty"`
-	MajorRiver              TERRAIN `json:"major river,omitempty"`
-	MinorRiver              TERRAIN `json:"minor river,omitempty"`
-	Mountain                TERRAIN `json:"mountain,omitempty"`
-	OffMapAirfield          TERRAIN `json:"off map airfield,omitempty"`
-	OffMapFlyingBoatBasin   TERRAIN `json:"off map flying boat basin,omitempty"`
-	Oasis                   TERRAIN `json:"oasis,omitempty"`
-	Port                    TERRAIN `json:"port,omitempty"`
-	Railroad                TERRAIN `json:"railroad,omitempty"`
-	Ridge                   TERRAIN `json:"ridge,omitempty"`
-	Road                    TERRAIN `json:"road,omitempty"`
-	RockGravel              TERRAIN `json:"rock/gravel,omitempty"`
-	Rough                   TERRAIN `json:"rough,omitempty"`
-	SaltMarsh               TERRAIN `json:"salt marsh,omitempty"`
-	Sea                     TERRAIN `json:"sea,omitempty"`
-	Slope                   TERRAIN `json:"slope,omitempty"`
-	Swamp                   TERRAIN `json:"swamp,omitempty"`
-	Track                   TERRAIN `json:"track,omitempty"`
-	TrainingArea            TERRAIN `json:"training area,omitempty"`
-	UnfinishedRailroad      TERRAIN `json:"unfinished railroad,omitempty"`
-	UnfinishedRoad          TERRAIN `json:"unfinished road,omitempty"`
-	VillageBir              TERRAIN `json:"village/bir,omitempty"`
-	Wadi                    TERRAIN `json:"wadi,omitempty"`
+// Adjacent reports whether b is next to a and, if so, the direction from a to b.
+func (m *MAP) Adjacent(a, b *HEX) (DIRECTION, bool) {
+	for _, d := range Directions {
+		if dRow, dCol := d.delta(a.Row); a.Row+dRow == b.Row && a.Column+dCol == b.Column {
+			return d, true
+		}
+	}
+	return 0, false
 }
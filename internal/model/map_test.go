@@ -0,0 +1,43 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	m := &MAP{Hexes: map[string]*HEX{"01001": {Id: "01001", Row: 1, Column: 1, Label: "A0101"}}}
+	if hex := m.Lookup("A0101"); hex == nil || hex.Id != "01001" {
+		t.Fatalf("A0101: want hex 01001, got %v", hex)
+	}
+
+	// hexes added after the first lookup must still be found
+	m.Hexes["01002"] = &HEX{Id: "01002", Row: 1, Column: 2, Label: "A0102"}
+	if hex := m.Lookup("A0102"); hex == nil || hex.Id != "01002" {
+		t.Errorf("A0102: want hex 01002, got %v", hex)
+	}
+
+	// a relabelled hex is found after the index is rebuilt
+	m.Hexes["01001"].Label = "B0101"
+	m.Index()
+	if hex := m.Lookup("B0101"); hex == nil || hex.Id != "01001" {
+		t.Errorf("B0101: want hex 01001, got %v", hex)
+	} else if hex := m.Lookup("A0101"); hex != nil {
+		t.Errorf("A0101: want no hex, got %v", hex)
+	}
+}
@@ -0,0 +1,80 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+// RESULTS is the log of orders executed in a single phase.
+type RESULTS struct {
+	Turn       int          `json:"turn"`
+	Stage      int          `json:"stage"`
+	Phase      string       `json:"phase"`
+	Results    []*RESULT    `json:"results"`
+	Violations []*VIOLATION `json:"violations,omitempty"` // rules broken when the orders were done
+}
+
+// RESULT is what happened to a single order.
+type RESULT struct {
+	Side   SIDE     `json:"side"`
+	Line   int      `json:"line"`  // line of the order in the player's file
+	Order  string   `json:"order"` // text of the order
+	Status STATUS   `json:"status"`
+	Reason string   `json:"reason,omitempty"` // why the order was rejected or cut short
+	Notes  []string `json:"notes,omitempty"`  // what the order did
+}
+
+// VIOLATION is a rule that is broken in a hex, such as over-stacking.
+type VIOLATION struct {
+	Rule    string `json:"rule"`
+	Hex     string `json:"hex"`
+	Side    SIDE   `json:"side"`
+	Message string `json:"message"`
+}
+
+// String implements fmt.Stringer
+func (v *VIOLATION) String() string {
+	return v.Hex + ": " + v.Side.String() + ": " + v.Message
+}
+
+// STATUS is the outcome of an order.
+type STATUS string
+
+const (
+	Success  STATUS = "success"
+	Partial  STATUS = "partial"
+	Rejected STATUS = "rejected"
+)
+
+// BySide returns the results for the side's orders.
+func (r *RESULTS) BySide(side SIDE) (results []*RESULT) {
+	for _, result := range r.Results {
+		if result.Side == side {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// ViolationsBySide returns the rules broken by the side.
+func (r *RESULTS) ViolationsBySide(side SIDE) (violations []*VIOLATION) {
+	for _, v := range r.Violations {
+		if v.Side == side {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
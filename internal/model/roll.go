@@ -0,0 +1,61 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ROLL is a record of dice thrown during the game.
+// The record holds everything needed to throw the same dice again
+// from the game's seed.
+type ROLL struct {
+	Turn    int    `json:"turn"`
+	Stage   int    `json:"stage"`
+	Phase   string `json:"phase"`
+	Side    SIDE   `json:"side,omitempty"` // side the roll was made for, none if both sides see it
+	Context string `json:"context"`        // what the roll is for, like "axis:12" for an order on line 12
+	Index   int    `json:"index"`          // rolls made earlier in the same phase and context
+	Purpose string `json:"purpose"`        // why the roll was made, like "close assault"
+	Sides   int    `json:"sides"`          // sides on each die
+	Dice    []int  `json:"dice"`           // the result of each die
+}
+
+// Key is the input that the roll is derived from.
+func (r *ROLL) Key() string {
+	return fmt.Sprintf("%d/%d/%s/%s/%d", r.Turn, r.Stage, r.Phase, r.Context, r.Index)
+}
+
+// Total returns the sum of the dice.
+func (r *ROLL) Total() (total int) {
+	for _, d := range r.Dice {
+		total += d
+	}
+	return total
+}
+
+// String implements fmt.Stringer
+func (r *ROLL) String() string {
+	var dice []string
+	for _, d := range r.Dice {
+		dice = append(dice, fmt.Sprintf("%d", d))
+	}
+	return fmt.Sprintf("%s: %s: %dd%d = %s", r.Key(), r.Purpose, len(r.Dice), r.Sides, strings.Join(dice, " "))
+}
@@ -0,0 +1,119 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SUPPLY is one of the four kinds of supply tracked by the game.
+type SUPPLY int
+
+const (
+	NoSupply SUPPLY = iota
+	Fuel
+	Ammo
+	Stores
+	Water
+)
+
+var supplyNames = []string{"", "fuel", "ammo", "stores", "water"}
+
+// Supplies is every kind of supply.
+var Supplies = []SUPPLY{Fuel, Ammo, Stores, Water}
+
+// ParseSupply converts a supply name. Names are not case-sensitive.
+func ParseSupply(s string) (SUPPLY, error) {
+	n, err := parseName("supply", supplyNames, s)
+	return SUPPLY(n), err
+}
+
+// String implements fmt.Stringer
+func (s SUPPLY) String() string {
+	return nameOf("SUPPLY", supplyNames, int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s SUPPLY) MarshalText() ([]byte, error) {
+	return marshalName("supply", supplyNames, int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *SUPPLY) UnmarshalText(b []byte) (err error) {
+	*s, err = ParseSupply(string(b))
+	return err
+}
+
+// STOCK is the amount of each kind of supply held by a unit or dump.
+// Kinds that are missing are zero.
+type STOCK map[SUPPLY]int
+
+// String implements fmt.Stringer
+func (s STOCK) String() string {
+	var parts []string
+	for _, kind := range Supplies {
+		parts = append(parts, fmt.Sprintf("%s %d", kind, s[kind]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DUMP is a supply dump. There is at most one dump in a hex.
+type DUMP struct {
+	Hex   string `json:"hex"`
+	Side  SIDE   `json:"side"`
+	Stock STOCK  `json:"stock,omitempty"`
+}
+
+// DumpId returns the ledger id of the dump in the hex.
+// Units are identified in the ledger by their own ids.
+func DumpId(hex string) string {
+	return "dump:" + hex
+}
+
+// TankId returns the ledger id of a truck unit's own tanks.
+func TankId(unit string) string {
+	return "tank:" + unit
+}
+
+// ENTRY is a single movement of supply in the logistics ledger.
+// From and To are the ledger ids of the holders. From is blank for
+// supply entering the game and To is blank for supply that is used
+// up or lost.
+type ENTRY struct {
+	Turn   int    `json:"turn"`
+	Stage  int    `json:"stage"`
+	Phase  string `json:"phase"`
+	Side   SIDE   `json:"side"`
+	Kind   string `json:"kind"` // one of the Entry kinds
+	Supply SUPPLY `json:"supply"`
+	Amount int    `json:"amount"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Kinds of ledger entries.
+const (
+	EntryReceive     = "receive"     // supply enters the game
+	EntryDraw        = "draw"        // supply is used up
+	EntryTransfer    = "transfer"    // supply moves between holders
+	EntryEvaporation = "evaporation" // supply is lost to evaporation
+	EntryLoss        = "loss"        // supply is destroyed
+)
@@ -0,0 +1,243 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TERRAIN is the primary terrain in a hex.
+// Features that run along hexsides (escarpments, wadis, roads) are
+// recorded on the HEXSIDE, and features that sit in the hex (ports,
+// oases, airfields) are recorded as FEATURES.
+type TERRAIN int
+
+const (
+	NoTerrain TERRAIN = iota
+	Clear
+	Delta
+	Desert
+	HeavyVegetation
+	Mountain
+	RockGravel
+	Rough
+	SaltMarsh
+	Sea
+	Swamp
+)
+
+// COLOR is a color in hue, saturation, lightness form.
+// Saturation and lightness are between 0 and 1.
+type COLOR struct {
+	Hue, Saturation, Lightness float64
+}
+
+// String returns the color as a CSS hsl() value.
+// Percentages are rounded to one decimal place.
+func (c COLOR) String() string {
+	percent := func(f float64) float64 {
+		return math.Round(f*1000) / 10
+	}
+	return fmt.Sprintf("hsl(%g, %g%%, %g%%)", c.Hue, percent(c.Saturation), percent(c.Lightness))
+}
+
+// ATTRIBUTES are the per-terrain values shared by the renderers and the rule engine.
+type ATTRIBUTES struct {
+	Name    string   // canonical name, used in JSON and data files
+	Aliases []string // other names accepted on import
+	Fill    COLOR    // background color when rendering the hex
+	Sea     bool     // true if land units may not enter the hex
+}
+
+// TerrainAttributes is the lookup table for terrain attributes.
+var TerrainAttributes = map[TERRAIN]ATTRIBUTES{
+	Clear:           {Name: "Clear", Fill: COLOR{53, 1.00, 0.94}},
+	Delta:           {Name: "Delta", Fill: COLOR{74, 0.48, 0.76}},
+	Desert:          {Name: "Desert", Fill: COLOR{48, 0.81, 0.66}},
+	HeavyVegetation: {Name: "Heavy Vegetation", Aliases: []string{"Vegetation"}, Fill: COLOR{85, 0.56, 0.71}},
+	Mountain:        {Name: "Mountain", Fill: COLOR{47, 0.40, 0.63}},
+	RockGravel:      {Name: "Rock/Gravel", Aliases: []string{"Rock", "Gravel"}, Fill: COLOR{49, 0.79, 0.89}},
+	Rough:           {Name: "Rough", Fill: COLOR{43, 0.43, 0.77}},
+	SaltMarsh:       {Name: "Salt Marsh", Fill: COLOR{65, 0.85, 0.90}},
+	Sea:             {Name: "Sea", Aliases: []string{"Ocean"}, Fill: COLOR{197, 0.78, 0.85}, Sea: true},
+	Swamp:           {Name: "Swamp", Fill: COLOR{68, 0.78, 0.93}},
+}
+
+// ParseTerrain converts a terrain name from the hex database.
+// Names are not case-sensitive and aliases are accepted.
+func ParseTerrain(s string) (TERRAIN, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return NoTerrain, nil
+	}
+	for t, attr := range TerrainAttributes {
+		if strings.EqualFold(s, attr.Name) {
+			return t, nil
+		}
+		for _, alias := range attr.Aliases {
+			if strings.EqualFold(s, alias) {
+				return t, nil
+			}
+		}
+	}
+	return NoTerrain, fmt.Errorf("unknown terrain %q", s)
+}
+
+// Attributes returns the attributes for the terrain.
+// The zero value is returned for NoTerrain.
+func (t TERRAIN) Attributes() ATTRIBUTES {
+	return TerrainAttributes[t]
+}
+
+// String implements fmt.Stringer
+func (t TERRAIN) String() string {
+	if attr, ok := TerrainAttributes[t]; ok {
+		return attr.Name
+	} else if t == NoTerrain {
+		return ""
+	}
+	return fmt.Sprintf("TERRAIN(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t TERRAIN) MarshalText() ([]byte, error) {
+	if _, ok := TerrainAttributes[t]; !ok && t != NoTerrain {
+		return nil, fmt.Errorf("invalid terrain %d", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *TERRAIN) UnmarshalText(b []byte) (err error) {
+	*t, err = ParseTerrain(string(b))
+	return err
+}
+
+// FEATURES is the set of features that sit in a hex.
+type FEATURES uint
+
+const (
+	Airfield FEATURES = 1 << iota
+	Coast
+	FlyingBoatAlightingArea
+	FlyingBoatBasin
+	MajorCity
+	Oasis
+	OffMapAirfield
+	OffMapFlyingBoatBasin
+	Port
+	TrainingArea
+	VillageBir
+)
+
+// featureNames is in the same order as the constants.
+// The canonical name is first, followed by any aliases.
+var featureNames = [][]string{
+	{"Airfield"},
+	{"Coast"},
+	{"Flying Boat Alighting Area"},
+	{"Flying Boat Basin"},
+	{"Major City", "City"},
+	{"Oasis"},
+	{"Off Map Airfield"},
+	{"Off Map Flying Boat Basin"},
+	{"Port"},
+	{"Training Area"},
+	{"Village/Bir", "Village", "Bir"},
+}
+
+// ParseFeatures converts the habitation column from the hex database.
+// Multiple features are separated by commas or semicolons. The column
+// also holds names, like towns, that are not features. It returns the
+// features it recognizes along with an error naming any it does not.
+func ParseFeatures(s string) (FEATURES, error) {
+	var features FEATURES
+	var unknown []string
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		f, err := parseFeature(strings.TrimSpace(field))
+		if err != nil {
+			unknown = append(unknown, fmt.Sprintf("%q", strings.TrimSpace(field)))
+			continue
+		}
+		features |= f
+	}
+	if len(unknown) != 0 {
+		return features, fmt.Errorf("unknown feature %s", strings.Join(unknown, ", "))
+	}
+	return features, nil
+}
+
+func parseFeature(s string) (FEATURES, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for n, names := range featureNames {
+		for _, name := range names {
+			if strings.EqualFold(s, name) {
+				return 1 << n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown feature %q", s)
+}
+
+// Has returns true if every feature in f is in the set.
+func (fs FEATURES) Has(f FEATURES) bool {
+	return fs&f == f
+}
+
+// Names returns the canonical name of every feature in the set.
+func (fs FEATURES) Names() (names []string) {
+	for n := range featureNames {
+		if fs&(1<<n) != 0 {
+			names = append(names, featureNames[n][0])
+		}
+	}
+	return names
+}
+
+// String implements fmt.Stringer
+func (fs FEATURES) String() string {
+	return strings.Join(fs.Names(), ", ")
+}
+
+// MarshalJSON implements json.Marshaler
+func (fs FEATURES) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fs.Names())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (fs *FEATURES) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	*fs = 0
+	for _, name := range names {
+		f, err := parseFeature(name)
+		if err != nil {
+			return err
+		}
+		*fs |= f
+	}
+	return nil
+}
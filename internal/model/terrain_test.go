@@ -0,0 +1,36 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import "testing"
+
+func TestColorString(t *testing.T) {
+	for _, tc := range []struct {
+		c    COLOR
+		want string
+	}{
+		{COLOR{85, 0.56, 0.71}, "hsl(85, 56%, 71%)"},
+		{COLOR{120, 0.60, 0.625}, "hsl(120, 60%, 62.5%)"},
+		{COLOR{120, 0.60, 0.4 + 0.45/3}, "hsl(120, 60%, 55%)"},
+	} {
+		if got := tc.c.String(); got != tc.want {
+			t.Errorf("%v: want %q, got %q", tc.c, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UNITS is a sortable slice of UNIT values
+type UNITS []*UNIT
+
+// UNIT is the data for a single counter.
+type UNIT struct {
+	Id          string      `json:"id"`
+	Name        string      `json:"name,omitempty"`
+	Nationality NATIONALITY `json:"nationality"`
+	Parent      string      `json:"parent,omitempty"` // id of the formation the unit belongs to
+	Type        UNITTYPE    `json:"type"`
+	Size        SIZE        `json:"size"`
+	Class       CLASS       `json:"class"`
+	TOE         int         `json:"toe"`               // current TOE strength points
+	Morale      int         `json:"morale"`            // morale rating
+	Cohesion    int         `json:"cohesion"`          // current cohesion level; negative is disorganized
+	CPA         int         `json:"cpa"`               // capability point allowance
+	CP          float64     `json:"cp"`                // capability points spent this operations stage
+	Reserve     bool        `json:"reserve,omitempty"` // designated as a reserve this operations stage
+	Hex         string      `json:"hex,omitempty"`
+	Stock       STOCK       `json:"stock,omitempty"`  // supply carried by the unit; a truck unit's cargo
+	Tank        STOCK       `json:"tank,omitempty"`   // fuel in a truck unit's own tanks, kept apart from its cargo
+	Thirst      int         `json:"thirst,omitempty"` // operations stages in a row without water
+}
+
+// Side returns the side the unit fights for.
+func (u *UNIT) Side() SIDE {
+	return u.Nationality.Side()
+}
+
+// ById returns the unit with the given id, or nil if there is no such unit.
+func (u UNITS) ById(id string) *UNIT {
+	for _, unit := range u {
+		if unit.Id == id {
+			return unit
+		}
+	}
+	return nil
+}
+
+// Len implements sort.Interface
+func (u UNITS) Len() int {
+	return len(u)
+}
+
+// Less implements sort.Interface
+func (u UNITS) Less(i, j int) bool {
+	return u[i].Id < u[j].Id
+}
+
+// Swap implements sort.Interface
+func (u UNITS) Swap(i, j int) {
+	u[i], u[j] = u[j], u[i]
+}
+
+// SIDE is one of the two players.
+type SIDE int
+
+const (
+	NoSide SIDE = iota
+	Axis
+	Commonwealth
+)
+
+var sideNames = []string{"", "axis", "commonwealth"}
+
+// Enemy returns the other side.
+func (s SIDE) Enemy() SIDE {
+	switch s {
+	case Axis:
+		return Commonwealth
+	case Commonwealth:
+		return Axis
+	}
+	return NoSide
+}
+
+// ParseSide converts a side name. Names are not case-sensitive.
+func ParseSide(s string) (SIDE, error) {
+	n, err := parseName("side", sideNames, s)
+	return SIDE(n), err
+}
+
+// String implements fmt.Stringer
+func (s SIDE) String() string {
+	return nameOf("SIDE", sideNames, int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s SIDE) MarshalText() ([]byte, error) {
+	return marshalName("side", sideNames, int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *SIDE) UnmarshalText(b []byte) (err error) {
+	*s, err = ParseSide(string(b))
+	return err
+}
+
+// NATIONALITY is the army a unit belongs to.
+type NATIONALITY int
+
+const (
+	NoNationality NATIONALITY = iota
+	German
+	Italian
+	British
+	Australian
+	NewZealand
+	Indian
+	SouthAfrican
+	FreeFrench
+	Polish
+)
+
+var nationalityNames = []string{"", "german", "italian", "british", "australian", "new zealand", "indian", "south african", "free french", "polish"}
+
+// Side returns the side the nationality fights for.
+func (n NATIONALITY) Side() SIDE {
+	switch n {
+	case German, Italian:
+		return Axis
+	case NoNationality:
+		return NoSide
+	}
+	return Commonwealth
+}
+
+// ParseNationality converts a nationality name. Names are not case-sensitive.
+func ParseNationality(s string) (NATIONALITY, error) {
+	n, err := parseName("nationality", nationalityNames, s)
+	return NATIONALITY(n), err
+}
+
+// String implements fmt.Stringer
+func (n NATIONALITY) String() string {
+	return nameOf("NATIONALITY", nationalityNames, int(n))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (n NATIONALITY) MarshalText() ([]byte, error) {
+	return marshalName("nationality", nationalityNames, int(n))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (n *NATIONALITY) UnmarshalText(b []byte) (err error) {
+	*n, err = ParseNationality(string(b))
+	return err
+}
+
+// UNITTYPE is the function of a unit.
+type UNITTYPE int
+
+const (
+	NoUnitType UNITTYPE = iota
+	Infantry
+	Armor
+	Recon
+	Artillery
+	AntiTank
+	AntiAircraft
+	Engineer
+	Headquarters
+	Truck
+)
+
+var unitTypeNames = []string{"", "infantry", "armor", "recon", "artillery", "anti-tank", "anti-aircraft", "engineer", "headquarters", "truck"}
+
+// ParseUnitType converts a unit type name. Names are not case-sensitive.
+func ParseUnitType(s string) (UNITTYPE, error) {
+	n, err := parseName("unit type", unitTypeNames, s)
+	return UNITTYPE(n), err
+}
+
+// String implements fmt.Stringer
+func (t UNITTYPE) String() string {
+	return nameOf("UNITTYPE", unitTypeNames, int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t UNITTYPE) MarshalText() ([]byte, error) {
+	return marshalName("unit type", unitTypeNames, int(t))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *UNITTYPE) UnmarshalText(b []byte) (err error) {
+	*t, err = ParseUnitType(string(b))
+	return err
+}
+
+// SIZE is the size of a unit's formation.
+type SIZE int
+
+const (
+	NoSize SIZE = iota
+	Company
+	Battalion
+	Regiment
+	Brigade
+	Division
+	Corps
+)
+
+var sizeNames = []string{"", "company", "battalion", "regiment", "brigade", "division", "corps"}
+
+// ParseSize converts a size name. Names are not case-sensitive.
+func ParseSize(s string) (SIZE, error) {
+	n, err := parseName("size", sizeNames, s)
+	return SIZE(n), err
+}
+
+// String implements fmt.Stringer
+func (s SIZE) String() string {
+	return nameOf("SIZE", sizeNames, int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s SIZE) MarshalText() ([]byte, error) {
+	return marshalName("size", sizeNames, int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *SIZE) UnmarshalText(b []byte) (err error) {
+	*s, err = ParseSize(string(b))
+	return err
+}
+
+// parseName returns the index of s in names.
+// An empty string is always index zero.
+func parseName(kind string, names []string, s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	for n, name := range names[1:] {
+		if strings.EqualFold(s, name) {
+			return n + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q", kind, s)
+}
+
+// nameOf returns the name for n, or a debugging value if n is out of range.
+func nameOf(kind string, names []string, n int) string {
+	if 0 <= n && n < len(names) {
+		return names[n]
+	}
+	return fmt.Sprintf("%s(%d)", kind, n)
+}
+
+// marshalName returns the name for n, or an error if n is out of range.
+func marshalName(kind string, names []string, n int) ([]byte, error) {
+	if !(0 <= n && n < len(names)) {
+		return nil, fmt.Errorf("invalid %s %d", kind, n)
+	}
+	return []byte(names[n]), nil
+}
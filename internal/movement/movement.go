@@ -0,0 +1,193 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package movement computes the capability points a unit spends to enter a hex.
+package movement
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"io/ioutil"
+)
+
+//go:embed costs.json
+var defaultCosts []byte
+
+// COSTS is the capability point cost for each class.
+// A class that is missing from the map may not enter or cross.
+type COSTS map[model.CLASS]float64
+
+// TABLE is the data that drives the movement cost engine.
+//
+// Terrain is the cost to enter a hex with that terrain.
+// Elevation and Water are added to the terrain cost when crossing
+// a hexside with that feature. Elevation is as seen from the hex
+// the unit is leaving.
+// Trans is the cost to enter a hex along a road, track, or railroad.
+// When a hexside has a usable route, the unit pays the lower of the
+// route cost and the cross-country cost.
+type TABLE struct {
+	Terrain   map[model.TERRAIN]COSTS   `json:"terrain"`
+	Elevation map[model.ELEVATION]COSTS `json:"elevation"`
+	Water     map[model.WATER]COSTS     `json:"water"`
+	Trans     map[model.TRANS]COSTS     `json:"trans"`
+}
+
+// Default returns the cost table that is built into the engine.
+func Default() (*TABLE, error) {
+	return decode(defaultCosts)
+}
+
+// Load reads a cost table from a JSON file.
+func Load(name string) (*TABLE, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	t, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+func decode(b []byte) (*TABLE, error) {
+	t := &TABLE{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(t); err != nil {
+		return nil, err
+	}
+
+	// entering a hex must cost something or the path search will loop
+	for terrain, costs := range t.Terrain {
+		for class, cost := range costs {
+			if !(cost > 0) {
+				return nil, fmt.Errorf("terrain %q: %s: cost must be positive", terrain, class)
+			}
+		}
+	}
+	for trans, costs := range t.Trans {
+		for class, cost := range costs {
+			if !(cost > 0) {
+				return nil, fmt.Errorf("trans %q: %s: cost must be positive", trans, class)
+			}
+		}
+	}
+	for elevation, costs := range t.Elevation {
+		for class, cost := range costs {
+			if cost < 0 {
+				return nil, fmt.Errorf("elevation %q: %s: cost must not be negative", elevation, class)
+			}
+		}
+	}
+	for water, costs := range t.Water {
+		for class, cost := range costs {
+			if cost < 0 {
+				return nil, fmt.Errorf("water %q: %s: cost must not be negative", water, class)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// ENGINE answers movement cost questions for a board.
+type ENGINE struct {
+	board *model.MAP
+	table *TABLE
+}
+
+// New returns an engine for the board using the given cost table.
+func New(board *model.MAP, table *TABLE) *ENGINE {
+	return &ENGINE{board: board, table: table}
+}
+
+// Cost returns the capability points a unit of the given class spends
+// to move from the hex into its neighbor in direction d.
+// A route lets a unit cross a hexside that it could not cross
+// cross-country, but never lets it enter terrain that it may not enter.
+// It returns an error if the move is not allowed.
+func (e *ENGINE) Cost(from *model.HEX, d model.DIRECTION, class model.CLASS) (float64, error) {
+	to := e.board.Neighbor(from, d)
+	if to == nil {
+		return 0, fmt.Errorf("%s: no hex to the %s", from.Label, d)
+	} else if _, ok := e.table.Terrain[to.Terrain][class]; !ok {
+		return 0, fmt.Errorf("%s %s to %s: %s may not enter %q", from.Label, d, to.Label, class, to.Terrain)
+	}
+	side := e.board.Hexside(from, d)
+
+	// cost along a road, track, or railroad, if there is one the class can use
+	routeCost, routeOk := e.table.Trans[side.Trans][class]
+
+	// cost to move cross-country
+	crossCost, crossErr := e.crossCountry(to, side, class)
+
+	if crossErr != nil {
+		if routeOk {
+			return routeCost, nil
+		}
+		return 0, fmt.Errorf("%s %s to %s: %w", from.Label, d, to.Label, crossErr)
+	} else if routeOk && routeCost < crossCost {
+		return routeCost, nil
+	}
+	return crossCost, nil
+}
+
+// crossCountry returns the cost to enter a hex without using a route.
+func (e *ENGINE) crossCountry(to *model.HEX, side model.HEXSIDE, class model.CLASS) (float64, error) {
+	cost, ok := e.table.Terrain[to.Terrain][class]
+	if !ok {
+		return 0, fmt.Errorf("%s may not enter %q", class, to.Terrain)
+	}
+	if side.Elevation != model.NoElevation {
+		extra, ok := e.table.Elevation[side.Elevation][class]
+		if !ok {
+			return 0, fmt.Errorf("%s may not cross %q", class, side.Elevation)
+		}
+		cost += extra
+	}
+	if side.Water != model.NoWater {
+		extra, ok := e.table.Water[side.Water][class]
+		if !ok {
+			return 0, fmt.Errorf("%s may not cross %q", class, side.Water)
+		}
+		cost += extra
+	}
+	return cost, nil
+}
+
+// MinCost returns the least a unit of the given class can spend to enter any hex.
+// The path search uses it to estimate the remaining cost to a goal.
+func (e *ENGINE) MinCost(class model.CLASS) float64 {
+	min := 0.0
+	for _, costs := range e.table.Terrain {
+		if cost, ok := costs[class]; ok && (min == 0 || cost < min) {
+			min = cost
+		}
+	}
+	for _, costs := range e.table.Trans {
+		if cost, ok := costs[class]; ok && (min == 0 || cost < min) {
+			min = cost
+		}
+	}
+	return min
+}
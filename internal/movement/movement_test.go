@@ -0,0 +1,123 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package movement
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"testing"
+)
+
+// board returns a small board of desert, three rows by five columns.
+// A road runs east along row 2. Row 3 has a mountain at column 3 with
+// a road leading into it from the west, row 1 has sea at column 3, and
+// a river runs along the east side of R1C1.
+// Hexes are labelled by row and column, like "R2C1".
+func board() *model.MAP {
+	m := &model.MAP{Hexes: make(map[string]*model.HEX)}
+	for row := 1; row <= 3; row++ {
+		for col := 1; col <= 5; col++ {
+			hex := &model.HEX{Row: row, Column: col, Label: fmt.Sprintf("R%dC%d", row, col), Terrain: model.Desert}
+			m.Hexes[fmt.Sprintf("%02d%03d", row, col)] = hex
+			m.Sorted = append(m.Sorted, hex)
+		}
+	}
+	m.At(3, 3).Terrain = model.Mountain
+	m.At(1, 3).Terrain = model.Sea
+	m.At(1, 1).Sides.E.Water = model.River
+	for col := 1; col < 5; col++ {
+		m.SetTrans(m.At(2, col), model.DirE, model.Road)
+	}
+	m.SetTrans(m.At(3, 2), model.DirE, model.Road)
+	return m
+}
+
+func TestCost(t *testing.T) {
+	m := board()
+	table, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := New(m, table)
+	for _, tc := range []struct {
+		row, col int
+		d        model.DIRECTION
+		class    model.CLASS
+		want     float64
+		ok       bool
+	}{
+		{2, 1, model.DirE, model.ClassMotorized, 0.5, true}, // along the road
+		{2, 1, model.DirE, model.ClassFoot, 1, true},        // road is cheaper than desert
+		{1, 4, model.DirE, model.ClassMotorized, 3, true},   // cross-country desert
+		{1, 1, model.DirE, model.ClassMotorized, 7, true},   // desert and a river
+		{1, 2, model.DirW, model.ClassMotorized, 7, true},   // the river seen from the other side
+		{3, 2, model.DirE, model.ClassFoot, 1, true},        // road into the mountain
+		{3, 2, model.DirE, model.ClassMotorized, 0, false},  // the road does not open the mountain
+		{1, 2, model.DirE, model.ClassFoot, 0, false},       // sea
+		{2, 5, model.DirE, model.ClassFoot, 0, false},       // edge of the board
+	} {
+		from := m.At(tc.row, tc.col)
+		got, err := engine.Cost(from, tc.d, tc.class)
+		if !tc.ok {
+			if err == nil {
+				t.Errorf("%s %s %s: want error, got %g", from.Label, tc.d, tc.class, got)
+			}
+		} else if err != nil {
+			t.Errorf("%s %s %s: unexpected error %v", from.Label, tc.d, tc.class, err)
+		} else if got != tc.want {
+			t.Errorf("%s %s %s: want %g, got %g", from.Label, tc.d, tc.class, tc.want, got)
+		}
+	}
+}
+
+func TestMinCost(t *testing.T) {
+	table, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := New(board(), table)
+	for class, want := range map[model.CLASS]float64{model.ClassMotorized: 0.5, model.ClassFoot: 1} {
+		if got := engine.MinCost(class); got != want {
+			t.Errorf("%s: want %g, got %g", class, want, got)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		src string
+		ok  bool
+	}{
+		{`{"terrain": {"Clear": {"foot": 1}}}`, true},
+		{`{"terrain": {"Clear": {"foot": 0}}}`, false},
+		{`{"trans": {"Road": {"foot": -1}}}`, false},
+		{`{"elevation": {"Ridge": {"foot": -1}}}`, false},
+		{`{"water": {"River": {"foot": -1}}}`, false},
+		{`{"terrain": {"Lava": {"foot": 1}}}`, false},
+		{`{"terrain": {"Clear": {"hover": 1}}}`, false},
+		{`{"bridges": {}}`, false},
+	} {
+		_, err := decode([]byte(tc.src))
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.src, err)
+		} else if !tc.ok && err == nil {
+			t.Errorf("%s: want error, got none", tc.src)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package orders
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"github.com/mdhender/tcfna/internal/turn"
+	"github.com/mdhender/tcfna/internal/visibility"
+)
+
+// Check validates the orders against the game without changing it.
+// It reports every problem it finds, not just the first.
+//
+// Every order is checked against the game as it is now. The executor
+// checks each order just before carrying it out, after the orders
+// before it, so an order that depends on an earlier one in the file,
+// like building a dump in the hex a unit has just moved into, may be
+// reported here but still succeed.
+func Check(g *model.GAME, engine *movement.ENGINE, f *FILE) error {
+	c := NewChecker(g, engine, f.Side)
+	var errs ERRORS
+	for _, o := range f.Orders {
+		if err := c.Order(o); err != nil {
+			errs = append(errs, err.(ERRORS)...)
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// CHECKER checks a side's orders one at a time. It remembers the units
+// given orders earlier in the file.
+type CHECKER struct {
+	g      *model.GAME
+	engine *movement.ENGINE
+	side   model.SIDE
+	moved  map[string]int // line each unit was first given a movement order
+	fired  map[string]int // line each unit was first given a combat order
+	line   int
+	errs   ERRORS
+}
+
+// NewChecker returns a checker for the side's orders.
+func NewChecker(g *model.GAME, engine *movement.ENGINE, side model.SIDE) *CHECKER {
+	return &CHECKER{g: g, engine: engine, side: side, moved: make(map[string]int), fired: make(map[string]int)}
+}
+
+// Order checks a single order against the game as it is now.
+// It returns ERRORS listing every problem with the order.
+func (c *CHECKER) Order(o ORDER) error {
+	c.line, c.errs = o.Pos().Line, nil
+	if err := turn.Legal(c.g, c.side, o.Verb()); err != nil {
+		c.fail("%v", err)
+	} else {
+		c.check(o)
+	}
+	if len(c.errs) != 0 {
+		return c.errs
+	}
+	return nil
+}
+
+func (c *CHECKER) fail(format string, args ...interface{}) {
+	c.errs = append(c.errs, &ERROR{Line: c.line, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *CHECKER) check(o ORDER) {
+	switch o := o.(type) {
+	case *MOVE:
+		if u := c.unit(o.Unit); u != nil {
+			c.once(u)
+			c.path(u, o.Path)
+		}
+	case *RAIL:
+		if o.Unit == "" {
+			c.hex(o.From)
+		} else if u := c.unit(o.Unit); u != nil {
+			c.once(u)
+		}
+		c.hex(o.To)
+	case *ATTACK:
+		target := c.target(o.Target)
+		for _, id := range o.Units {
+			u := c.unit(id)
+			if u != nil {
+				c.fires(u)
+			}
+			if u != nil && target != nil {
+				if _, ok := c.g.Map.Adjacent(c.g.Map.Lookup(u.Hex), target); !ok {
+					c.fail("unit %q in %s is not adjacent to %s", u.Id, u.Hex, target.Label)
+				}
+			}
+		}
+	case *BARRAGE:
+		c.target(o.Target)
+		for _, id := range o.Units {
+			if u := c.unit(id); u != nil {
+				c.fires(u)
+			}
+		}
+	case *RETREAT:
+		if u := c.unit(o.Unit); u != nil {
+			c.once(u)
+			c.path(u, o.Path)
+		}
+	case *RESERVE:
+		c.unit(o.Unit)
+	case *BUILD:
+		if hex := c.hex(o.Hex); hex != nil && len(c.own(hex)) == 0 {
+			c.fail("no %s units in %s", c.side, hex.Label)
+		} else if hex != nil && o.Railroad && c.g.Map.Hexside(hex, o.Direction).Trans != model.UnfinishedRailroad {
+			c.fail("the %s side of %s is not unfinished railroad", o.Direction, hex.Label)
+		}
+	case *LOAD:
+		c.truck(o.Unit)
+	case *UNLOAD:
+		c.truck(o.Unit)
+	case *HAUL:
+		if u := c.truck(o.Unit); u != nil {
+			c.once(u)
+		}
+		c.hex(o.To)
+	case *CONVOY:
+		if hex := c.hex(o.Port); hex != nil && !hex.Features.Has(model.Port) {
+			c.fail("%s is not a port", hex.Label)
+		}
+	case *FLY:
+		c.unit(o.Unit)
+		c.hex(o.Target)
+	case *REPAIR:
+		c.unit(o.Unit)
+	case *ORGANIZE:
+		u, parent := c.unit(o.Unit), c.g.Units.ById(o.Parent)
+		if parent == nil {
+			c.fail("unknown unit %q", o.Parent)
+		} else if parent.Side() != c.side {
+			c.fail("unit %q does not belong to %s", o.Parent, c.side)
+		} else if parent.Type != model.Headquarters {
+			c.fail("unit %q is not a headquarters", o.Parent)
+		} else if u != nil && u == parent {
+			c.fail("unit %q can not be its own parent", o.Unit)
+		}
+	default:
+		c.fail("unexpected %q order", o.Verb())
+	}
+}
+
+// unit returns the unit if it exists, belongs to the side, and is on the map.
+func (c *CHECKER) unit(id string) *model.UNIT {
+	u := c.g.Units.ById(id)
+	if u == nil {
+		c.fail("unknown unit %q", id)
+		return nil
+	} else if u.Side() != c.side {
+		c.fail("unit %q does not belong to %s", id, c.side)
+		return nil
+	} else if u.Hex == "" {
+		c.fail("unit %q is not on the map", id)
+		return nil
+	}
+	return u
+}
+
+// truck returns the unit if it is a truck unit that belongs to the side.
+func (c *CHECKER) truck(id string) *model.UNIT {
+	u := c.unit(id)
+	if u != nil && u.Type != model.Truck {
+		c.fail("unit %q is not a truck unit", id)
+		return nil
+	}
+	return u
+}
+
+// once reports units that are given more than one movement order.
+func (c *CHECKER) once(u *model.UNIT) {
+	if line, ok := c.moved[u.Id]; ok {
+		c.fail("unit %q was already given a movement order on line %d", u.Id, line)
+		return
+	}
+	c.moved[u.Id] = c.line
+}
+
+// fires reports units that are given more than one combat order.
+func (c *CHECKER) fires(u *model.UNIT) {
+	if line, ok := c.fired[u.Id]; ok {
+		c.fail("unit %q was already given a combat order on line %d", u.Id, line)
+		return
+	}
+	c.fired[u.Id] = c.line
+}
+
+// hex returns the hex if it exists and is not a sea hex.
+func (c *CHECKER) hex(label string) *model.HEX {
+	hex := c.g.Map.Lookup(label)
+	if hex == nil {
+		c.fail("unknown hex %q", label)
+		return nil
+	} else if hex.Terrain.Attributes().Sea {
+		c.fail("%s is a sea hex", label)
+		return nil
+	}
+	return hex
+}
+
+// target returns the hex if it exists and the side can see enemy units
+// in it. A hex the side can not see is reported the same way as an
+// empty one so that orders can not be used to find hidden units.
+func (c *CHECKER) target(label string) *model.HEX {
+	hex := c.hex(label)
+	if hex == nil {
+		return nil
+	}
+	if visibility.Compute(c.g, visibility.Default, c.side).Sees(hex) {
+		for _, u := range c.g.UnitsAt(hex.Label) {
+			if u.Side() == c.side.Enemy() {
+				return hex
+			}
+		}
+	}
+	c.fail("no enemy units seen in %s", hex.Label)
+	return nil
+}
+
+// own returns the side's units in the hex.
+func (c *CHECKER) own(hex *model.HEX) (units model.UNITS) {
+	for _, u := range c.g.UnitsAt(hex.Label) {
+		if u.Side() == c.side {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// path checks that every hex on the path is next to the one before it,
+// starting from the unit's hex, and that the unit's class may make each move.
+func (c *CHECKER) path(u *model.UNIT, path []string) {
+	from := c.g.Map.Lookup(u.Hex)
+	for _, label := range path {
+		to := c.hex(label)
+		if to == nil || from == nil {
+			return
+		}
+		d, ok := c.g.Map.Adjacent(from, to)
+		if !ok {
+			c.fail("%s is not adjacent to %s", to.Label, from.Label)
+			return
+		} else if _, err := c.engine.Cost(from, d, u.Class); err != nil {
+			c.fail("unit %q: %v", u.Id, err)
+			return
+		}
+		from = to
+	}
+}
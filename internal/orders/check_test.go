@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package orders
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"sort"
+	"testing"
+)
+
+// game returns an axis close assault segment on a single row of clear
+// hexes labelled "C1" to "C8". An axis infantry unit is in C1 and
+// British units are in C2, where the axis can see them, and in C6,
+// where it can not.
+func game(t *testing.T) (*model.GAME, *movement.ENGINE) {
+	t.Helper()
+	board := &model.MAP{Hexes: make(map[string]*model.HEX)}
+	for col := 1; col <= 8; col++ {
+		hex := &model.HEX{Row: 1, Column: col, Label: fmt.Sprintf("C%d", col), Terrain: model.Clear}
+		board.Hexes[fmt.Sprintf("%02d%03d", 1, col)] = hex
+		board.Sorted = append(board.Sorted, hex)
+	}
+	sort.Sort(board.Sorted)
+	table, err := movement.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &model.GAME{
+		Map:        board,
+		Turn:       1,
+		Stage:      1,
+		Phase:      "First Player Close Assault Segment",
+		Initiative: model.Axis,
+		Units: model.UNITS{
+			{Id: "21pz/104", Nationality: model.German, Type: model.Infantry, Class: model.ClassFoot, Hex: "C1"},
+			{Id: "7armd/4armdbde", Nationality: model.British, Type: model.Armor, Class: model.ClassTrack, Hex: "C2"},
+			{Id: "4ind/5indbde", Nationality: model.British, Type: model.Infantry, Class: model.ClassFoot, Hex: "C6"},
+		},
+	}
+	return g, movement.New(board, table)
+}
+
+func TestCheckTarget(t *testing.T) {
+	g, engine := game(t)
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"side axis\nattack C2 with 21pz/104\n", ""},
+		// a hidden unit and an empty hex must be reported the same way
+		{"side axis\nattack C6 with 21pz/104\n", "2: no enemy units seen in C6"},
+		{"side axis\nattack C7 with 21pz/104\n", "2: no enemy units seen in C7"},
+	} {
+		f, err := Parse([]byte(tc.src))
+		if err != nil {
+			t.Fatalf("%q: %v", tc.src, err)
+		}
+		err = Check(g, engine, f)
+		if tc.want == "" && err != nil {
+			t.Errorf("%q: unexpected error %v", tc.src, err)
+		} else if tc.want != "" && (err == nil || err.Error() != tc.want) {
+			t.Errorf("%q: want error %q, got %v", tc.src, tc.want, err)
+		}
+	}
+}
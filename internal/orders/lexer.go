@@ -0,0 +1,90 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package orders
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// TOKEN is a word or quoted string from an order file.
+type TOKEN struct {
+	Line, Col int
+	Text      string
+	Quoted    bool
+}
+
+// String implements fmt.Stringer
+func (t TOKEN) String() string {
+	if t.Quoted {
+		return fmt.Sprintf("%q", t.Text)
+	}
+	return t.Text
+}
+
+// lex splits the input into lines of tokens.
+// Words are separated by spaces or tabs. A "#" starts a comment that
+// runs to the end of the line. Double quotes group words that contain
+// spaces; there are no escapes inside quotes.
+// Lines with no tokens are dropped.
+func lex(src []byte) (lines [][]TOKEN, errs ERRORS) {
+	var line []TOKEN
+	lineNo, col := 1, 0
+	input := []rune(string(src))
+	for pos := 0; pos < len(input); {
+		ch := input[pos]
+		col++
+		switch {
+		case ch == '\n':
+			if len(line) != 0 {
+				lines = append(lines, line)
+			}
+			line, lineNo, col = nil, lineNo+1, 0
+			pos++
+		case ch == '#':
+			for pos < len(input) && input[pos] != '\n' {
+				pos++
+			}
+		case unicode.IsSpace(ch):
+			pos++
+		case ch == '"':
+			start, startCol := pos+1, col
+			for pos++; pos < len(input) && input[pos] != '"' && input[pos] != '\n'; pos++ {
+				col++
+			}
+			if pos == len(input) || input[pos] == '\n' {
+				errs = append(errs, &ERROR{Line: lineNo, Col: startCol, Msg: "unterminated quoted string"})
+				continue
+			}
+			line = append(line, TOKEN{Line: lineNo, Col: startCol, Text: string(input[start:pos]), Quoted: true})
+			pos++
+			col++
+		default:
+			start, startCol := pos, col
+			for pos++; pos < len(input) && !unicode.IsSpace(input[pos]) && input[pos] != '#' && input[pos] != '"'; pos++ {
+				col++
+			}
+			line = append(line, TOKEN{Line: lineNo, Col: startCol, Text: string(input[start:pos])})
+		}
+	}
+	if len(line) != 0 {
+		lines = append(lines, line)
+	}
+	return lines, errs
+}
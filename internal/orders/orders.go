@@ -0,0 +1,218 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package orders parses and checks player order files.
+//
+// An order file is plain text with one order per line. Blank lines are
+// ignored and "#" starts a comment. The first order must name the side
+// that the orders are for. Unit ids and hex labels are single words;
+// use double quotes for anything with spaces.
+//
+//	side axis
+//	move 21pz/5pzrgt C4709 C4710 C4810   # path of adjacent hexes
+//	rail 21pz/104 to C4120
+//	rail 40 fuel from C4120 to C4709
+//	attack C4811 with 21pz/5pzrgt 21pz/104
+//	barrage C4811 with 21pz/155art
+//	retreat 7armd/4armdbde C4912 C5012
+//	reserve 21pz/3recon
+//	build dump at C4708
+//	build railroad at C4120 ne
+//	load 21pz/supply 20 fuel
+//	unload 21pz/supply 20 fuel
+//	haul 21pz/supply to C4120
+//	convoy C4708 40 ammo
+//	fly 1/jg27 recon C4811
+//	repair 21pz/5pzrgt
+//	organize 21pz/104 under 15pz
+package orders
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"strings"
+)
+
+// ORDER is a single parsed order.
+type ORDER interface {
+	// Verb is the first word of the order.
+	Verb() string
+	// Pos returns the line the order was given on and its text.
+	Pos() POS
+}
+
+// POS is where an order came from.
+type POS struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Pos implements ORDER
+func (p POS) Pos() POS {
+	return p
+}
+
+// MOVE moves a unit along a path of adjacent hexes.
+type MOVE struct {
+	POS
+	Unit string
+	Path []string // labels of the hexes entered, in order
+}
+
+// RAIL moves a unit, or supply between dumps, by rail.
+type RAIL struct {
+	POS
+	Unit   string // blank when moving supply
+	Amount int
+	Supply model.SUPPLY
+	From   string // label of the dump the supply is taken from
+	To     string
+}
+
+// ATTACK is a close assault on the units in a hex.
+type ATTACK struct {
+	POS
+	Target string
+	Units  []string
+}
+
+// BARRAGE is an artillery barrage on the units in a hex.
+type BARRAGE struct {
+	POS
+	Target string
+	Units  []string
+}
+
+// RETREAT is a retreat before assault along a path of adjacent hexes.
+type RETREAT struct {
+	POS
+	Unit string
+	Path []string
+}
+
+// RESERVE designates a unit as a reserve.
+type RESERVE struct {
+	POS
+	Unit string
+}
+
+// BUILD creates a supply dump in a hex or starts construction on a
+// hexside of unfinished railroad.
+type BUILD struct {
+	POS
+	Railroad  bool
+	Hex       string
+	Direction model.DIRECTION // side of the hex, for railroad only
+}
+
+// LOAD moves supply from the dump in a truck's hex onto the truck.
+type LOAD struct {
+	POS
+	Unit   string
+	Amount int
+	Supply model.SUPPLY
+}
+
+// UNLOAD moves supply from a truck into the dump in its hex.
+type UNLOAD struct {
+	POS
+	Unit   string
+	Amount int
+	Supply model.SUPPLY
+}
+
+// HAUL moves a truck unit to a hex along the roads and unloads its
+// cargo into the dump there.
+type HAUL struct {
+	POS
+	Unit string
+	To   string
+}
+
+// CONVOY schedules a naval convoy to a port.
+type CONVOY struct {
+	POS
+	Port   string
+	Amount int
+	Supply model.SUPPLY
+}
+
+// FLY sends an air unit on a mission against a hex.
+type FLY struct {
+	POS
+	Unit    string
+	Mission string
+	Target  string
+}
+
+// REPAIR repairs a unit.
+type REPAIR struct {
+	POS
+	Unit string
+}
+
+// ORGANIZE attaches a unit to a new parent formation.
+type ORGANIZE struct {
+	POS
+	Unit   string
+	Parent string
+}
+
+func (o *MOVE) Verb() string     { return "move" }
+func (o *RAIL) Verb() string     { return "rail" }
+func (o *ATTACK) Verb() string   { return "attack" }
+func (o *BARRAGE) Verb() string  { return "barrage" }
+func (o *RETREAT) Verb() string  { return "retreat" }
+func (o *RESERVE) Verb() string  { return "reserve" }
+func (o *BUILD) Verb() string    { return "build" }
+func (o *LOAD) Verb() string     { return "load" }
+func (o *UNLOAD) Verb() string   { return "unload" }
+func (o *HAUL) Verb() string     { return "haul" }
+func (o *CONVOY) Verb() string   { return "convoy" }
+func (o *FLY) Verb() string      { return "fly" }
+func (o *REPAIR) Verb() string   { return "repair" }
+func (o *ORGANIZE) Verb() string { return "organize" }
+
+// Missions is the list of air missions.
+var Missions = []string{"recon", "bomb", "strafe", "cover"}
+
+// ERROR is a problem with an order file.
+type ERROR struct {
+	Line, Col int
+	Msg       string
+}
+
+// Error implements the error interface
+func (e *ERROR) Error() string {
+	if e.Col == 0 {
+		return fmt.Sprintf("%d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// ERRORS is every problem found in an order file.
+type ERRORS []*ERROR
+
+// Error implements the error interface
+func (e ERRORS) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
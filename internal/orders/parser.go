@@ -0,0 +1,378 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package orders
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FILE is a parsed order file.
+type FILE struct {
+	Side   model.SIDE
+	Orders []ORDER
+}
+
+// Parse parses an order file.
+// It reports every syntax error it finds, not just the first.
+func Parse(src []byte) (*FILE, error) {
+	lines, errs := lex(src)
+	text := strings.Split(string(src), "\n")
+
+	// don't pile parse errors on top of lexing errors
+	bad := make(map[int]bool)
+	for _, err := range errs {
+		bad[err.Line] = true
+	}
+
+	f := &FILE{}
+	for _, tokens := range lines {
+		p := &parser{tokens: tokens, line: tokens[0].Line}
+		if bad[p.line] {
+			continue
+		}
+		p.text = strings.TrimSpace(text[p.line-1])
+		if n := strings.Index(p.text, "#"); n != -1 && !strings.Contains(p.text[:n], `"`) {
+			p.text = strings.TrimSpace(p.text[:n])
+		}
+
+		verb := strings.ToLower(tokens[0].Text)
+		if verb == "side" {
+			side, err := p.side()
+			if err != nil {
+				errs = append(errs, err)
+			} else if f.Side != model.NoSide {
+				errs = append(errs, &ERROR{Line: p.line, Col: tokens[0].Col, Msg: "side given more than once"})
+			} else if len(f.Orders) != 0 {
+				errs = append(errs, &ERROR{Line: p.line, Col: tokens[0].Col, Msg: "side must be given before any orders"})
+			} else {
+				f.Side = side
+			}
+			continue
+		}
+
+		parse, ok := verbs[verb]
+		if !ok {
+			errs = append(errs, &ERROR{Line: p.line, Col: tokens[0].Col, Msg: fmt.Sprintf("unknown order %q", tokens[0].Text)})
+			continue
+		}
+		p.pos = 1
+		o, err := parse(p)
+		if err == nil && p.pos < len(p.tokens) {
+			t := p.tokens[p.pos]
+			err = &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unexpected %s", t)}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.Orders = append(f.Orders, o)
+	}
+
+	if f.Side == model.NoSide && len(errs) == 0 {
+		errs = append(errs, &ERROR{Line: 1, Msg: "missing side"})
+	}
+	if len(errs) != 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Line < errs[j].Line
+		})
+		return nil, errs
+	}
+	return f, nil
+}
+
+// verbs maps each order verb to the function that parses the rest of the line.
+var verbs = map[string]func(p *parser) (ORDER, *ERROR){
+	"move": func(p *parser) (ORDER, *ERROR) {
+		o := &MOVE{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		o.Path, err = p.words("hex")
+		return o, err
+	},
+	"rail": func(p *parser) (ORDER, *ERROR) {
+		o := &RAIL{POS: p.at()}
+		var err *ERROR
+		if p.number() {
+			if o.Amount, o.Supply, err = p.supply(); err != nil {
+				return nil, err
+			} else if err = p.keyword("from"); err != nil {
+				return nil, err
+			} else if o.From, err = p.word("hex"); err != nil {
+				return nil, err
+			}
+		} else if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		if err = p.keyword("to"); err != nil {
+			return nil, err
+		}
+		o.To, err = p.word("hex")
+		return o, err
+	},
+	"attack": func(p *parser) (ORDER, *ERROR) {
+		o := &ATTACK{POS: p.at()}
+		var err *ERROR
+		if o.Target, err = p.word("hex"); err != nil {
+			return nil, err
+		} else if err = p.keyword("with"); err != nil {
+			return nil, err
+		}
+		o.Units, err = p.words("unit")
+		return o, err
+	},
+	"barrage": func(p *parser) (ORDER, *ERROR) {
+		o := &BARRAGE{POS: p.at()}
+		var err *ERROR
+		if o.Target, err = p.word("hex"); err != nil {
+			return nil, err
+		} else if err = p.keyword("with"); err != nil {
+			return nil, err
+		}
+		o.Units, err = p.words("unit")
+		return o, err
+	},
+	"retreat": func(p *parser) (ORDER, *ERROR) {
+		o := &RETREAT{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		o.Path, err = p.words("hex")
+		return o, err
+	},
+	"reserve": func(p *parser) (ORDER, *ERROR) {
+		o := &RESERVE{POS: p.at()}
+		var err *ERROR
+		o.Unit, err = p.word("unit")
+		return o, err
+	},
+	"build": func(p *parser) (ORDER, *ERROR) {
+		o := &BUILD{POS: p.at()}
+		t, err := p.token(`"dump" or "railroad"`)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !t.Quoted && strings.EqualFold(t.Text, "dump"):
+		case !t.Quoted && strings.EqualFold(t.Text, "railroad"):
+			o.Railroad = true
+		default:
+			return nil, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf(`expected "dump" or "railroad", found %s`, t)}
+		}
+		if err = p.keyword("at"); err != nil {
+			return nil, err
+		} else if o.Hex, err = p.word("hex"); err != nil {
+			return nil, err
+		} else if !o.Railroad {
+			return o, nil
+		}
+		if t, err = p.token("direction"); err != nil {
+			return nil, err
+		}
+		d, derr := model.ParseDirection(t.Text)
+		if derr != nil {
+			return nil, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unknown direction %s", t)}
+		}
+		o.Direction = d
+		return o, nil
+	},
+	"load": func(p *parser) (ORDER, *ERROR) {
+		o := &LOAD{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		o.Amount, o.Supply, err = p.supply()
+		return o, err
+	},
+	"unload": func(p *parser) (ORDER, *ERROR) {
+		o := &UNLOAD{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		o.Amount, o.Supply, err = p.supply()
+		return o, err
+	},
+	"haul": func(p *parser) (ORDER, *ERROR) {
+		o := &HAUL{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		} else if err = p.keyword("to"); err != nil {
+			return nil, err
+		}
+		o.To, err = p.word("hex")
+		return o, err
+	},
+	"convoy": func(p *parser) (ORDER, *ERROR) {
+		o := &CONVOY{POS: p.at()}
+		var err *ERROR
+		if o.Port, err = p.word("port hex"); err != nil {
+			return nil, err
+		}
+		o.Amount, o.Supply, err = p.supply()
+		return o, err
+	},
+	"fly": func(p *parser) (ORDER, *ERROR) {
+		o := &FLY{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		}
+		t, err := p.token("mission")
+		if err != nil {
+			return nil, err
+		}
+		for _, mission := range Missions {
+			if strings.EqualFold(t.Text, mission) {
+				o.Mission = mission
+			}
+		}
+		if o.Mission == "" {
+			return nil, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unknown mission %q", t.Text)}
+		}
+		o.Target, err = p.word("hex")
+		return o, err
+	},
+	"repair": func(p *parser) (ORDER, *ERROR) {
+		o := &REPAIR{POS: p.at()}
+		var err *ERROR
+		o.Unit, err = p.word("unit")
+		return o, err
+	},
+	"organize": func(p *parser) (ORDER, *ERROR) {
+		o := &ORGANIZE{POS: p.at()}
+		var err *ERROR
+		if o.Unit, err = p.word("unit"); err != nil {
+			return nil, err
+		} else if err = p.keyword("under"); err != nil {
+			return nil, err
+		}
+		o.Parent, err = p.word("unit")
+		return o, err
+	},
+}
+
+// parser walks the tokens on a single line.
+type parser struct {
+	tokens []TOKEN
+	pos    int
+	line   int
+	text   string
+}
+
+// at returns the position of the order being parsed.
+func (p *parser) at() POS {
+	return POS{Line: p.line, Text: p.text}
+}
+
+// token returns the next token or an error naming what was expected.
+func (p *parser) token(what string) (TOKEN, *ERROR) {
+	if p.pos == len(p.tokens) {
+		last := p.tokens[len(p.tokens)-1]
+		return TOKEN{}, &ERROR{Line: last.Line, Col: last.Col + len([]rune(last.Text)) + 1, Msg: fmt.Sprintf("expected %s", what)}
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+// word returns the text of the next token.
+func (p *parser) word(what string) (string, *ERROR) {
+	t, err := p.token(what)
+	return t.Text, err
+}
+
+// words returns the text of the rest of the tokens on the line.
+// There must be at least one.
+func (p *parser) words(what string) (words []string, err *ERROR) {
+	if _, err = p.token(what); err != nil {
+		return nil, err
+	}
+	for _, t := range p.tokens[p.pos-1:] {
+		words = append(words, t.Text)
+	}
+	p.pos = len(p.tokens)
+	return words, nil
+}
+
+// keyword consumes the next token, which must be the keyword.
+func (p *parser) keyword(k string) *ERROR {
+	t, err := p.token(fmt.Sprintf("%q", k))
+	if err != nil {
+		return err
+	} else if t.Quoted || !strings.EqualFold(t.Text, k) {
+		return &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("expected %q, found %s", k, t)}
+	}
+	return nil
+}
+
+// number returns true if the next token is a number.
+func (p *parser) number() bool {
+	if p.pos == len(p.tokens) || p.tokens[p.pos].Quoted {
+		return false
+	}
+	_, err := strconv.Atoi(p.tokens[p.pos].Text)
+	return err == nil
+}
+
+// supply parses an amount and a kind of supply.
+func (p *parser) supply() (int, model.SUPPLY, *ERROR) {
+	t, err := p.token("amount")
+	if err != nil {
+		return 0, model.NoSupply, err
+	}
+	amount, cerr := strconv.Atoi(t.Text)
+	if cerr != nil || amount <= 0 {
+		return 0, model.NoSupply, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("amount must be a positive number, found %s", t)}
+	}
+	if t, err = p.token("supply type"); err != nil {
+		return 0, model.NoSupply, err
+	}
+	supply, serr := model.ParseSupply(t.Text)
+	if serr != nil || supply == model.NoSupply {
+		return 0, model.NoSupply, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unknown supply type %s", t)}
+	}
+	return amount, supply, nil
+}
+
+// side parses the side declaration.
+func (p *parser) side() (model.SIDE, *ERROR) {
+	p.pos = 1
+	t, err := p.token("side")
+	if err != nil {
+		return model.NoSide, err
+	}
+	side, serr := model.ParseSide(t.Text)
+	if serr != nil || side == model.NoSide {
+		return model.NoSide, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unknown side %s", t)}
+	}
+	if p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		return model.NoSide, &ERROR{Line: t.Line, Col: t.Col, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+	return side, nil
+}
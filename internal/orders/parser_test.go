@@ -0,0 +1,100 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package orders
+
+import (
+	"github.com/mdhender/tcfna/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestParseVerbs(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want ORDER
+	}{
+		{"move 21pz/5pzrgt C4709 C4710", &MOVE{Unit: "21pz/5pzrgt", Path: []string{"C4709", "C4710"}}},
+		{"rail 21pz/104 to C4120", &RAIL{Unit: "21pz/104", To: "C4120"}},
+		{"rail 40 fuel from C4120 to C4709", &RAIL{Amount: 40, Supply: model.Fuel, From: "C4120", To: "C4709"}},
+		{"attack C4811 with 21pz/5pzrgt 21pz/104", &ATTACK{Target: "C4811", Units: []string{"21pz/5pzrgt", "21pz/104"}}},
+		{"barrage C4811 with 21pz/155art", &BARRAGE{Target: "C4811", Units: []string{"21pz/155art"}}},
+		{"retreat 7armd/4armdbde C4912 C5012", &RETREAT{Unit: "7armd/4armdbde", Path: []string{"C4912", "C5012"}}},
+		{"reserve 21pz/3recon", &RESERVE{Unit: "21pz/3recon"}},
+		{"build dump at C4708", &BUILD{Hex: "C4708"}},
+		{"build railroad at C4120 ne", &BUILD{Railroad: true, Hex: "C4120", Direction: model.DirNE}},
+		{"load 21pz/supply 20 fuel", &LOAD{Unit: "21pz/supply", Amount: 20, Supply: model.Fuel}},
+		{"unload 21pz/supply 20 water", &UNLOAD{Unit: "21pz/supply", Amount: 20, Supply: model.Water}},
+		{"haul 21pz/supply to C4120", &HAUL{Unit: "21pz/supply", To: "C4120"}},
+		{"convoy C4708 40 ammo", &CONVOY{Port: "C4708", Amount: 40, Supply: model.Ammo}},
+		{"fly 1/jg27 recon C4811", &FLY{Unit: "1/jg27", Mission: "recon", Target: "C4811"}},
+		{"repair 21pz/5pzrgt", &REPAIR{Unit: "21pz/5pzrgt"}},
+		{"organize 21pz/104 under 15pz", &ORGANIZE{Unit: "21pz/104", Parent: "15pz"}},
+		{`MOVE "21pz/5pz rgt" C4709 # comment`, &MOVE{Unit: "21pz/5pz rgt", Path: []string{"C4709"}}},
+	} {
+		f, err := Parse([]byte("side axis\n" + tc.line + "\n"))
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.line, err)
+			continue
+		} else if f.Side != model.Axis {
+			t.Errorf("%q: side: want %v, got %v", tc.line, model.Axis, f.Side)
+		} else if len(f.Orders) != 1 {
+			t.Errorf("%q: want 1 order, got %d", tc.line, len(f.Orders))
+			continue
+		}
+		if pos := f.Orders[0].Pos(); pos.Line != 2 {
+			t.Errorf("%q: line: want 2, got %d", tc.line, pos.Line)
+		}
+		// the position is checked above; clear it so the rest can be compared
+		got := reflect.ValueOf(f.Orders[0]).Elem()
+		got.FieldByName("POS").Set(reflect.ValueOf(POS{}))
+		if !reflect.DeepEqual(f.Orders[0], tc.want) {
+			t.Errorf("%q: want %+v, got %+v", tc.line, tc.want, f.Orders[0])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"move 8armd/22gds A0101\n", "1: missing side"},
+		{"side axis\nside axis\n", "2:1: side given more than once"},
+		{"move 21pz/104 C4120\nside axis\n", "2:1: side must be given before any orders"},
+		{"side vichy\n", "1:6: unknown side vichy"},
+		{"side axis\nadvance 21pz/104\n", `2:1: unknown order "advance"`},
+		{"side axis\nmove 21pz/104\n", "2:15: expected hex"},
+		{"side axis\nreserve 21pz/104 now\n", "2:18: unexpected now"},
+		{"side axis\nattack C4811 21pz/104\n", `2:14: expected "with", found 21pz/104`},
+		{"side axis\nload 21pz/supply -5 fuel\n", "2:18: amount must be a positive number, found -5"},
+		{"side axis\nconvoy C4708 40 beer\n", "2:17: unknown supply type beer"},
+		{"side axis\nbuild bridge at C4708\n", `2:7: expected "dump" or "railroad", found bridge`},
+		{"side axis\nbuild railroad at C4120 up\n", "2:25: unknown direction up"},
+		{"side axis\nfly 1/jg27 escort C4811\n", `2:12: unknown mission "escort"`},
+		{"side axis\nmove \"21pz/104 C4120\n", "2:6: unterminated quoted string"},
+		{"side axis\nrepair\n\nadvance\n", "2:8: expected unit\n4:1: unknown order \"advance\""},
+	} {
+		_, err := Parse([]byte(tc.src))
+		if err == nil {
+			t.Errorf("%q: want error %q, got none", tc.src, tc.want)
+		} else if err.Error() != tc.want {
+			t.Errorf("%q: want error %q, got %q", tc.src, tc.want, err.Error())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package pq is the priority queue of hexes shared by the board searches.
+package pq
+
+import (
+	"container/heap"
+	"github.com/mdhender/tcfna/internal/model"
+)
+
+// QUEUE is a min-heap of hexes on priority. Hexes with the same
+// priority come out in the order they went in so that searches are
+// repeatable. The zero value is an empty queue.
+type QUEUE struct {
+	items items
+}
+
+// Len returns the number of entries in the queue.
+func (q *QUEUE) Len() int {
+	return len(q.items.list)
+}
+
+// Push adds the hex with the given priority. A hex may be pushed more
+// than once; callers skip the entries they have already finished.
+func (q *QUEUE) Push(hex *model.HEX, priority float64) {
+	heap.Push(&q.items, &item{hex: hex, priority: priority})
+}
+
+// Pop removes and returns the hex with the lowest priority.
+func (q *QUEUE) Pop() *model.HEX {
+	return heap.Pop(&q.items).(*item).hex
+}
+
+// item is an entry in the queue.
+type item struct {
+	hex      *model.HEX
+	priority float64
+	seq      int // breaks ties
+}
+
+// items implements heap.Interface.
+type items struct {
+	list []*item
+	seq  int
+}
+
+func (q *items) Len() int { return len(q.list) }
+
+func (q *items) Less(i, j int) bool {
+	if q.list[i].priority != q.list[j].priority {
+		return q.list[i].priority < q.list[j].priority
+	}
+	return q.list[i].seq < q.list[j].seq
+}
+
+func (q *items) Swap(i, j int) { q.list[i], q.list[j] = q.list[j], q.list[i] }
+
+func (q *items) Push(x interface{}) {
+	it := x.(*item)
+	it.seq, q.seq = q.seq, q.seq+1
+	q.list = append(q.list, it)
+}
+
+func (q *items) Pop() interface{} {
+	it := q.list[len(q.list)-1]
+	q.list = q.list[:len(q.list)-1]
+	return it
+}
@@ -0,0 +1,134 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package procedure carries out the automatic parts of the sequence of play.
+//
+// Advance moves the game to the next phase and then runs whatever the
+// rules require when that phase is entered, such as charging water in
+// the Stores Expenditure Phase. Procedures for optional rules only run
+// when the option is in play.
+package procedure
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/dice"
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/rail"
+	"github.com/mdhender/tcfna/internal/turn"
+)
+
+// RULES are the rules that the automatic procedures use.
+type RULES struct {
+	Water  logistics.WATERRULES
+	Fuel   logistics.FUELRULES
+	Trucks logistics.TRUCKRULES
+	Rail   rail.RULES
+}
+
+// Default is the standard rules.
+var Default = RULES{
+	Water:  logistics.DefaultWater,
+	Fuel:   logistics.DefaultFuel,
+	Trucks: logistics.DefaultTrucks,
+	Rail:   rail.Default,
+}
+
+// Advance moves the game to the next phase and runs the procedures for
+// that phase. It returns the results of the procedures, which may be empty.
+func Advance(g *model.GAME, rules RULES) (turn.STEP, *model.RESULTS, error) {
+	step, err := turn.Advance(g)
+	if err != nil {
+		return step, nil, err
+	}
+	results := &model.RESULTS{Turn: g.Turn, Stage: g.Stage, Phase: g.Phase}
+	ledger := logistics.New(g)
+
+	switch step.Phase.Name {
+	case turn.InitiativeDetermination:
+		roller, err := dice.New(g)
+		if err != nil {
+			return step, results, err
+		}
+		rolls, err := initiative(g, roller)
+		results.Results = append(results.Results, rolls...)
+		if err != nil {
+			return step, results, err
+		}
+	case turn.StoresExpenditure:
+		if g.Option("water") {
+			results.Results = append(results.Results, ledger.Drink(rules.Water)...)
+		}
+	case turn.FirstBreakdown, turn.SecondBreakdown:
+		if g.Option("breakdown") {
+			side := turn.Initiative(g)
+			if step.Phase.Name == turn.SecondBreakdown {
+				side = side.Enemy()
+			}
+			roller, err := dice.New(g)
+			if err != nil {
+				return step, results, err
+			}
+			rolls, err := ledger.Breakdown(rules.Trucks, roller, side)
+			results.Results = append(results.Results, rolls...)
+			if err != nil {
+				return step, results, err
+			}
+		}
+	case turn.Record:
+		results.Results = append(results.Results, rail.Construct(g, rules.Rail)...)
+		rail.Reset(g)
+	case turn.Evaporation:
+		if g.Option("water") {
+			results.Results = append(results.Results, ledger.EvaporateWater(rules.Water)...)
+		}
+		if g.Option("flimsies") {
+			results.Results = append(results.Results, ledger.EvaporateFuel(rules.Fuel)...)
+		}
+	}
+
+	return step, results, nil
+}
+
+// initiative rolls a die for each side and gives the initiative to the
+// higher roll. On a tie the side that had the initiative keeps it. It
+// returns the same result for each side.
+func initiative(g *model.GAME, roller *dice.ROLLER) (results []*model.RESULT, err error) {
+	keeps := turn.Initiative(g)
+	axis, err := roller.D6(model.NoSide, "initiative:axis", "initiative for axis")
+	if err != nil {
+		return nil, err
+	}
+	commonwealth, err := roller.D6(model.NoSide, "initiative:commonwealth", "initiative for commonwealth")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case axis > commonwealth:
+		g.Initiative = model.Axis
+	case commonwealth > axis:
+		g.Initiative = model.Commonwealth
+	default:
+		g.Initiative = keeps
+	}
+	note := fmt.Sprintf("axis rolled %d, commonwealth rolled %d, %s has the initiative", axis, commonwealth, g.Initiative)
+	for _, side := range []model.SIDE{model.Axis, model.Commonwealth} {
+		results = append(results, &model.RESULT{Side: side, Order: "initiative", Status: model.Success, Notes: []string{note}})
+	}
+	return results, nil
+}
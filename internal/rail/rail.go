@@ -0,0 +1,261 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package rail builds the railroad network from the board.
+//
+// The network is every hexside with a finished railroad, including
+// road and railroad hexsides. A side's trains run from its railhead
+// along the network and may not pass through hexes that hold enemy
+// units. Each side may move only so many tons by rail each game-turn.
+// Unfinished railroad is turned into railroad by engineers working on
+// it for several game-turns, which also extends the railhead.
+package rail
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+)
+
+// RULES control rail movement and construction.
+type RULES struct {
+	Capacity int // tons each side may move by rail each game-turn
+	UnitTons int // tons of capacity used by each TOE point of a unit
+	Turns    int // game-turns of work to finish a hexside of railroad
+	// Builders lists the unit types that can build railroad.
+	Builders map[model.UNITTYPE]bool
+}
+
+// Default is the standard rail rules.
+var Default = RULES{
+	Capacity: 60,
+	UnitTons: 2,
+	Turns:    3,
+	Builders: map[model.UNITTYPE]bool{
+		model.Engineer: true,
+	},
+}
+
+// Tons returns the rail capacity used to move the unit and its cargo.
+func (r RULES) Tons(u *model.UNIT) (tons int) {
+	tons = u.TOE * r.UnitTons
+	for _, amount := range u.Stock {
+		tons += amount
+	}
+	return tons
+}
+
+// NETWORK is the railroad network on a board.
+type NETWORK struct {
+	board *model.MAP
+	links map[*model.HEX][]*model.HEX // hexes joined by railroad
+}
+
+// Network returns the railroad network on the board.
+func Network(board *model.MAP) *NETWORK {
+	n := &NETWORK{board: board, links: make(map[*model.HEX][]*model.HEX)}
+	for _, hex := range board.Sorted {
+		for _, d := range model.Directions {
+			if to := board.Neighbor(hex, d); to != nil && board.Hexside(hex, d).Trans.HasRailroad() {
+				n.links[hex] = append(n.links[hex], to)
+			}
+		}
+	}
+	return n
+}
+
+// Has returns true if a railroad runs through the hex.
+func (n *NETWORK) Has(hex *model.HEX) bool {
+	return len(n.links[hex]) != 0
+}
+
+// Hexes returns every hex on the network, sorted.
+func (n *NETWORK) Hexes() (hexes model.HEXES) {
+	for hex := range n.links {
+		hexes = append(hexes, hex)
+	}
+	sort.Sort(hexes)
+	return hexes
+}
+
+// Path returns the shortest path along the railroad between two hexes,
+// including both ends. Hexes that are blocked can not be passed through
+// or entered. It returns an error if there is no path.
+func (n *NETWORK) Path(from, to *model.HEX, blocked func(*model.HEX) bool) ([]*model.HEX, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("missing hex")
+	} else if !n.Has(from) {
+		return nil, fmt.Errorf("no railroad in %s", from.Label)
+	} else if !n.Has(to) {
+		return nil, fmt.Errorf("no railroad in %s", to.Label)
+	}
+	prev := map[*model.HEX]*model.HEX{from: nil}
+	for q := []*model.HEX{from}; len(q) != 0; q = q[1:] {
+		hex := q[0]
+		if hex == to {
+			var path []*model.HEX
+			for ; hex != nil; hex = prev[hex] {
+				path = append([]*model.HEX{hex}, path...)
+			}
+			return path, nil
+		}
+		for _, next := range n.links[hex] {
+			if _, ok := prev[next]; ok || (blocked != nil && blocked(next)) {
+				continue
+			}
+			prev[next] = hex
+			q = append(q, next)
+		}
+	}
+	return nil, fmt.Errorf("no railroad from %s to %s", from.Label, to.Label)
+}
+
+// Enemy returns a function that blocks hexes holding the side's enemies.
+func Enemy(g *model.GAME, side model.SIDE) func(*model.HEX) bool {
+	enemy := make(map[*model.HEX]bool)
+	for _, u := range g.Units {
+		if u.Side() == side.Enemy() && u.Hex != "" {
+			enemy[g.Map.Lookup(u.Hex)] = true
+		}
+	}
+	return func(hex *model.HEX) bool {
+		return enemy[hex]
+	}
+}
+
+// Route returns the path the side's trains take between two hexes.
+// Trains run from the side's railhead, so both hexes must be reachable
+// from it without passing enemy units.
+func Route(g *model.GAME, side model.SIDE, from, to *model.HEX) ([]*model.HEX, error) {
+	p := g.Player(side)
+	if p == nil || p.Railhead == "" {
+		return nil, fmt.Errorf("%s has no railhead", side)
+	}
+	railhead := g.Map.Lookup(p.Railhead)
+	n, blocked := Network(g.Map), Enemy(g, side)
+	if blocked(railhead) {
+		return nil, fmt.Errorf("enemy units hold the railhead in %s", railhead.Label)
+	}
+	for _, hex := range []*model.HEX{from, to} {
+		if _, err := n.Path(railhead, hex, blocked); err != nil {
+			return nil, fmt.Errorf("%s is not connected to the railhead in %s", hex.Label, railhead.Label)
+		}
+	}
+	return n.Path(from, to, blocked)
+}
+
+// Left returns the tons the side may still move by rail this game-turn.
+func Left(g *model.GAME, rules RULES, side model.SIDE) int {
+	p := g.Player(side)
+	if p == nil || p.RailTons >= rules.Capacity {
+		return 0
+	}
+	return rules.Capacity - p.RailTons
+}
+
+// Start begins construction on a hexside of unfinished railroad.
+// The side must have a unit that can build railroad in the hex.
+func Start(g *model.GAME, rules RULES, side model.SIDE, hex *model.HEX, d model.DIRECTION) (*model.RAILWORK, error) {
+	if t := g.Map.Hexside(hex, d).Trans; t != model.UnfinishedRailroad {
+		return nil, fmt.Errorf("the %s side of %s is not unfinished railroad", d, hex.Label)
+	} else if !builders(g, rules, side, hex, d) {
+		return nil, fmt.Errorf("no %s units in %s can build railroad", side, hex.Label)
+	}
+	for _, w := range g.Rail {
+		if same(g, w, hex, d) {
+			return nil, fmt.Errorf("the %s side of %s is already being built by %s", d, hex.Label, w.Side)
+		}
+	}
+	w := &model.RAILWORK{Side: side, Hex: hex.Label, Direction: d}
+	g.Rail = append(g.Rail, w)
+	return w, nil
+}
+
+// Construct adds a game-turn of work to every unfinished hexside of
+// railroad that has a unit able to build railroad in either of its
+// hexes. A hexside that has had enough work becomes railroad. If the
+// builder's railhead is in one of its hexes, the railhead moves to the
+// other. It returns a result for each hexside worked on.
+func Construct(g *model.GAME, rules RULES) (results []*model.RESULT) {
+	for _, w := range g.Rail {
+		if w.Done {
+			continue
+		}
+		hex := g.Map.Lookup(w.Hex)
+		r := &model.RESULT{Side: w.Side, Order: fmt.Sprintf("railroad construction on the %s side of %s", w.Direction, w.Hex), Status: model.Success}
+		results = append(results, r)
+		if !builders(g, rules, w.Side, hex, w.Direction) {
+			r.Status, r.Reason = model.Partial, "no units to build it"
+			continue
+		}
+		w.Turns++
+		r.Notes = append(r.Notes, fmt.Sprintf("%d of %d game-turns of work done", w.Turns, rules.Turns))
+		if w.Turns < rules.Turns {
+			continue
+		}
+		w.Done = true
+		g.Map.SetTrans(hex, w.Direction, model.Railroad)
+		r.Notes = append(r.Notes, "finished")
+		to := g.Map.Neighbor(hex, w.Direction)
+		if p := g.Player(w.Side); p != nil && to != nil {
+			if p.Railhead == hex.Label {
+				p.Railhead = to.Label
+			} else if p.Railhead == to.Label {
+				p.Railhead = hex.Label
+			} else {
+				continue
+			}
+			r.Notes = append(r.Notes, fmt.Sprintf("railhead moved to %s", p.Railhead))
+		}
+	}
+	return results
+}
+
+// Reset clears the tons each side has moved by rail for a new game-turn.
+func Reset(g *model.GAME) {
+	for _, p := range g.Players {
+		p.RailTons = 0
+	}
+}
+
+// builders returns true if the side has a unit that can build railroad
+// in either hex of the hexside.
+func builders(g *model.GAME, rules RULES, side model.SIDE, hex *model.HEX, d model.DIRECTION) bool {
+	labels := []string{hex.Label}
+	if to := g.Map.Neighbor(hex, d); to != nil {
+		labels = append(labels, to.Label)
+	}
+	for _, label := range labels {
+		for _, u := range g.UnitsAt(label) {
+			if u.Side() == side && u.TOE > 0 && rules.Builders[u.Type] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// same returns true if the work is on the d side of the hex, as seen
+// from either of its hexes.
+func same(g *model.GAME, w *model.RAILWORK, hex *model.HEX, d model.DIRECTION) bool {
+	if w.Hex == hex.Label && w.Direction == d {
+		return true
+	}
+	to := g.Map.Neighbor(hex, d)
+	return to != nil && w.Hex == to.Label && w.Direction == d.Opposite()
+}
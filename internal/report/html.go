@@ -0,0 +1,97 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/store/memory"
+	"html/template"
+)
+
+// HTML returns the report as a page with the board at the top.
+// The board shows the hexes holding the side's units and the enemy
+// units that the side can see.
+func (r *REPORT) HTML(ds *memory.STORE) ([]byte, error) {
+	b := &bytes.Buffer{}
+	if err := page.Execute(b, r); err != nil {
+		return nil, err
+	}
+	return ds.PageAsHTML(fmt.Sprintf("%s: %s", r.Side, r.Title()), r, b.Bytes()), nil
+}
+
+// Overlay implements memory.OVERLAY. It shades hexes with the side's
+// units in blue and hexes with visible enemy units in red, and notes
+// the number of units in the hex.
+func (r *REPORT) Overlay(hex *model.HEX) (fill, note string) {
+	own, enemy := 0, 0
+	for _, u := range r.Units {
+		if u.Hex == hex.Label {
+			own++
+		}
+	}
+	for _, u := range r.Enemies {
+		if u.Hex == hex.Label {
+			enemy++
+		}
+	}
+	if own != 0 {
+		return model.COLOR{Hue: 210, Saturation: 0.60, Lightness: 0.70}.String(), fmt.Sprintf("%d", own)
+	} else if enemy != 0 {
+		return model.COLOR{Hue: 0, Saturation: 0.60, Lightness: 0.70}.String(), fmt.Sprintf("%d", enemy)
+	}
+	return "", ""
+}
+
+var page = template.Must(template.New("report").Parse(`
+<h1>{{.Title}}</h1>
+<p>Report for {{.Side}}{{with .Player}}{{with .Name}} ({{.}}){{end}}{{end}}</p>
+{{with .SeedHash}}<p>Dice seed hash {{.}}</p>{{end}}
+
+<h2>Units</h2>
+<table>
+<tr><th>Id</th><th>Type</th><th>Size</th><th>Hex</th><th>CP</th><th>TOE</th><th>Cohesion</th><th>Supply</th></tr>
+{{range .Units}}<tr><td>{{.Id}}</td><td>{{.Type}}</td><td>{{.Size}}</td><td>{{if .Hex}}{{.Hex}}{{else}}off map{{end}}</td><td>{{.CP}}/{{.CPA}}</td><td>{{.TOE}}</td><td>{{.Cohesion}}</td><td>{{.SupplyText}}</td></tr>
+{{end}}</table>
+
+<h2>Supplies</h2>
+{{if .Supplies}}<table>
+<tr><th>Holder</th><th>Hex</th><th>Fuel</th><th>Ammo</th><th>Stores</th><th>Water</th></tr>
+{{range .Supplies}}<tr><td>{{.Id}}</td><td>{{.Hex}}</td><td>{{.Fuel}}</td><td>{{.Ammo}}</td><td>{{.Stores}}</td><td>{{.Water}}</td></tr>
+{{end}}</table>{{else}}<p>none</p>{{end}}
+
+<h2>Orders</h2>
+{{if .Results}}<table>
+<tr><th>Line</th><th>Order</th><th>Status</th><th>Details</th></tr>
+{{range .Results}}<tr><td>{{.Line}}</td><td>{{.Order}}</td><td>{{.Status}}</td><td>{{with .Reason}}{{.}}<br>{{end}}{{range .Notes}}{{.}}<br>{{end}}</td></tr>
+{{end}}</table>{{else}}<p>none</p>{{end}}
+
+{{with .Violations}}<h2>Violations</h2>
+<table>
+<tr><th>Rule</th><th>Hex</th><th>Problem</th></tr>
+{{range .}}<tr><td>{{.Rule}}</td><td>{{.Hex}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>Enemy Units</h2>
+{{if .Enemies}}<table>
+<tr><th>Id</th><th>Nationality</th><th>Type</th><th>Size</th><th>Hex</th></tr>
+{{range .Enemies}}<tr><td>{{.Id}}</td><td>{{.Nationality}}</td><td>{{.Type}}</td><td>{{.Size}}</td><td>{{.Hex}}</td></tr>
+{{end}}</table>{{else}}<p>none seen</p>{{end}}
+`))
@@ -0,0 +1,94 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package report generates the turn report for each player.
+package report
+
+import (
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/supply"
+	"github.com/mdhender/tcfna/internal/visibility"
+)
+
+// REPORT is what one player is told at the end of a phase.
+type REPORT struct {
+	Side       model.SIDE
+	Player     *model.PLAYER // may be nil
+	Turn       int
+	Stage      int
+	Phase      string
+	SeedHash   string              // published hash of the dice seed
+	Units      []*UNIT             // the side's units, on and off the map
+	Supplies   []logistics.BALANCE // stock held by the side's dumps and units
+	Results    []*model.RESULT     // results of the side's orders
+	Violations []*model.VIOLATION  // rules the side is breaking
+	Enemies    model.UNITS         // enemy units the side can see
+}
+
+// UNIT is a unit and its supply status.
+type UNIT struct {
+	*model.UNIT
+	Supply supply.STATUS
+}
+
+// New builds the report for the side from the game and the results
+// of the last phase executed. The results may be nil.
+func New(g *model.GAME, results *model.RESULTS, side model.SIDE) *REPORT {
+	r := &REPORT{Side: side, Player: g.Player(side), Turn: g.Turn, Stage: g.Stage, Phase: g.Phase, SeedHash: g.SeedHash}
+	if results != nil {
+		r.Results = results.BySide(side)
+		r.Violations = results.ViolationsBySide(side)
+	}
+
+	var sources []*model.HEX
+	if r.Player != nil {
+		for _, label := range r.Player.Sources {
+			sources = append(sources, g.Map.Lookup(label))
+		}
+	}
+	// the trace is blocked only by the enemy units the side can see,
+	// so the report never gives away the position of a hidden unit
+	view := visibility.Compute(g, visibility.Default, side).Filter(g)
+	enemy := make(map[*model.HEX]bool)
+	for _, u := range view.Units {
+		if u.Side() == side.Enemy() && u.Hex != "" {
+			enemy[g.Map.Lookup(u.Hex)] = true
+		}
+	}
+	trace := supply.Trace(g.Map, supply.Default, sources, enemy)
+
+	for _, u := range g.Units {
+		if u.Side() != side {
+			continue
+		}
+		unit := &UNIT{UNIT: u}
+		if hex := g.Map.Lookup(u.Hex); hex != nil {
+			unit.Supply = trace.Status(hex)
+		}
+		r.Units = append(r.Units, unit)
+	}
+
+	r.Supplies = logistics.Balances(g, side)
+	for _, u := range view.Units {
+		if u.Side() != side {
+			r.Enemies = append(r.Enemies, u)
+		}
+	}
+	return r
+}
@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Title is the heading for the report.
+func (r *REPORT) Title() string {
+	title := fmt.Sprintf("Game-Turn %d", r.Turn)
+	if r.Stage != 0 {
+		title += fmt.Sprintf(", Operations Stage %d", r.Stage)
+	}
+	return title + ", " + r.Phase
+}
+
+// Text writes the report as plain text.
+func (r *REPORT) Text(w io.Writer) error {
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "%s\n", r.Title())
+	if r.Player != nil && r.Player.Name != "" {
+		_, _ = fmt.Fprintf(b, "Report for %s (%s)\n", r.Side, r.Player.Name)
+	} else {
+		_, _ = fmt.Fprintf(b, "Report for %s\n", r.Side)
+	}
+	if r.SeedHash != "" {
+		_, _ = fmt.Fprintf(b, "Dice seed hash %s\n", r.SeedHash)
+	}
+
+	_, _ = fmt.Fprintf(b, "\nUnits\n")
+	tw := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "  Id\tType\tSize\tHex\tCP\tTOE\tCohesion\tSupply\n")
+	for _, u := range r.Units {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%g/%d\t%d\t%d\t%s\n", u.Id, u.Type, u.Size, hexOf(u), u.CP, u.CPA, u.TOE, u.Cohesion, u.SupplyText())
+	}
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(b, "\nSupplies\n")
+	if len(r.Supplies) == 0 {
+		_, _ = fmt.Fprintf(b, "  none\n")
+	} else {
+		tw = tabwriter.NewWriter(b, 0, 0, 2, ' ', tabwriter.AlignRight)
+		_, _ = fmt.Fprintf(tw, "  Holder\tHex\tFuel\tAmmo\tStores\tWater\t\n")
+		for _, s := range r.Supplies {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t%d\t%d\t\n", s.Id, s.Hex, s.Fuel(), s.Ammo(), s.Stores(), s.Water())
+		}
+		_ = tw.Flush()
+	}
+
+	_, _ = fmt.Fprintf(b, "\nOrders\n")
+	if len(r.Results) == 0 {
+		_, _ = fmt.Fprintf(b, "  none\n")
+	}
+	for _, result := range r.Results {
+		_, _ = fmt.Fprintf(b, "  line %d: %s: %s\n", result.Line, result.Status, result.Order)
+		if result.Reason != "" {
+			_, _ = fmt.Fprintf(b, "      %s\n", result.Reason)
+		}
+		for _, note := range result.Notes {
+			_, _ = fmt.Fprintf(b, "      %s\n", note)
+		}
+	}
+
+	if len(r.Violations) != 0 {
+		_, _ = fmt.Fprintf(b, "\nViolations\n")
+		for _, v := range r.Violations {
+			_, _ = fmt.Fprintf(b, "  %s: %s: %s\n", v.Rule, v.Hex, v.Message)
+		}
+	}
+
+	_, _ = fmt.Fprintf(b, "\nEnemy Units\n")
+	if len(r.Enemies) == 0 {
+		_, _ = fmt.Fprintf(b, "  none seen\n")
+	}
+	tw = tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	for _, u := range r.Enemies {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", u.Id, u.Nationality, u.Type, u.Size, u.Hex)
+	}
+	_ = tw.Flush()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hexOf returns the unit's hex, or a note that it is off the map.
+func hexOf(u *UNIT) string {
+	if u.Hex == "" {
+		return "off map"
+	}
+	return u.Hex
+}
+
+// SupplyText returns a short description of the unit's supply status.
+func (u *UNIT) SupplyText() string {
+	if u.Hex == "" {
+		return ""
+	} else if !u.Supply.InSupply {
+		return "out of supply"
+	}
+	return fmt.Sprintf("%d hexes from %s", u.Supply.Hexes, u.Supply.Source.Label)
+}
@@ -0,0 +1,185 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package route finds the cheapest paths across the board.
+package route
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"github.com/mdhender/tcfna/internal/pq"
+)
+
+// COST returns the cost to move from a hex into its neighbor in direction d.
+// It returns false if the move is not allowed.
+type COST func(from *model.HEX, d model.DIRECTION) (float64, bool)
+
+// SEARCH finds paths over a board using a cost function.
+type SEARCH struct {
+	board *model.MAP
+	cost  COST
+	min   float64 // least cost to enter any hex, used to estimate the remaining cost
+}
+
+// New returns a search over the board.
+// The minimum cost must never be more than the cost of any single move
+// or the search may not return the cheapest path.
+func New(board *model.MAP, cost COST, min float64) *SEARCH {
+	return &SEARCH{board: board, cost: cost, min: min}
+}
+
+// ForClass returns a search that uses the movement cost engine's costs
+// for the given class.
+func ForClass(board *model.MAP, e *movement.ENGINE, class model.CLASS) *SEARCH {
+	return New(board, ClassCost(e, class), e.MinCost(class))
+}
+
+// ClassCost returns a cost function that uses the movement cost engine's
+// costs for the given class.
+func ClassCost(e *movement.ENGINE, class model.CLASS) COST {
+	return func(from *model.HEX, d model.DIRECTION) (float64, bool) {
+		cost, err := e.Cost(from, d, class)
+		return cost, err == nil
+	}
+}
+
+// PreferRoads wraps a cost function so that every move that is not
+// along a road or track costs the penalty more. Searches using it keep
+// to the roads unless leaving them saves more than the penalty.
+func PreferRoads(board *model.MAP, cost COST, penalty float64) COST {
+	return func(from *model.HEX, d model.DIRECTION) (float64, bool) {
+		c, ok := cost(from, d)
+		if !ok {
+			return c, ok
+		}
+		if t := board.Hexside(from, d).Trans; t != model.Track && !t.HasRoad() {
+			c += penalty
+		}
+		return c, true
+	}
+}
+
+// PATH is a route between two hexes.
+type PATH struct {
+	Hexes []*model.HEX // every hex on the path, including the start
+	Costs []float64    // cost to enter each hex; the start costs nothing
+	Total float64
+}
+
+// Shortest returns the cheapest path between two hexes using A*.
+// It returns an error if there is no path.
+func (s *SEARCH) Shortest(from, to *model.HEX) (*PATH, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("missing hex")
+	}
+
+	cost := map[*model.HEX]float64{from: 0}
+	prev := map[*model.HEX]*model.HEX{}
+	done := map[*model.HEX]bool{}
+
+	q := &pq.QUEUE{}
+	q.Push(from, s.estimate(from, to))
+	for q.Len() != 0 {
+		hex := q.Pop()
+		if done[hex] {
+			continue
+		}
+		done[hex] = true
+		if hex == to {
+			return s.path(from, to, cost, prev), nil
+		}
+		for _, d := range model.Directions {
+			neighbor := s.board.Neighbor(hex, d)
+			if neighbor == nil || done[neighbor] {
+				continue
+			}
+			step, ok := s.cost(hex, d)
+			if !ok {
+				continue
+			}
+			if c, ok := cost[neighbor]; ok && c <= cost[hex]+step {
+				continue
+			}
+			cost[neighbor], prev[neighbor] = cost[hex]+step, hex
+			q.Push(neighbor, cost[neighbor]+s.estimate(neighbor, to))
+		}
+	}
+
+	return nil, fmt.Errorf("no path from %s to %s", from.Label, to.Label)
+}
+
+// Reachable returns every hex that can be entered from the start
+// without spending more than the budget, along with the least cost to enter it.
+// The start is always included with a cost of zero.
+func (s *SEARCH) Reachable(from *model.HEX, budget float64) map[*model.HEX]float64 {
+	cost := map[*model.HEX]float64{from: 0}
+	done := map[*model.HEX]bool{}
+
+	q := &pq.QUEUE{}
+	q.Push(from, 0)
+	for q.Len() != 0 {
+		hex := q.Pop()
+		if done[hex] {
+			continue
+		}
+		done[hex] = true
+		for _, d := range model.Directions {
+			neighbor := s.board.Neighbor(hex, d)
+			if neighbor == nil || done[neighbor] {
+				continue
+			}
+			step, ok := s.cost(hex, d)
+			if !ok || cost[hex]+step > budget {
+				continue
+			}
+			if c, ok := cost[neighbor]; ok && c <= cost[hex]+step {
+				continue
+			}
+			cost[neighbor] = cost[hex] + step
+			q.Push(neighbor, cost[neighbor])
+		}
+	}
+
+	return cost
+}
+
+// estimate is the least the remaining path from h to the goal could cost.
+func (s *SEARCH) estimate(h, goal *model.HEX) float64 {
+	return float64(h.Distance(goal)) * s.min
+}
+
+// path walks the previous links back from the goal to build the path.
+func (s *SEARCH) path(from, to *model.HEX, cost map[*model.HEX]float64, prev map[*model.HEX]*model.HEX) *PATH {
+	var hexes []*model.HEX
+	for h := to; h != from; h = prev[h] {
+		hexes = append(hexes, h)
+	}
+	hexes = append(hexes, from)
+
+	p := &PATH{Total: cost[to]}
+	for i := len(hexes) - 1; i >= 0; i-- {
+		p.Hexes = append(p.Hexes, hexes[i])
+		if i == len(hexes)-1 {
+			p.Costs = append(p.Costs, 0)
+		} else {
+			p.Costs = append(p.Costs, cost[hexes[i]]-cost[hexes[i+1]])
+		}
+	}
+	return p
+}
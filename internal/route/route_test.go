@@ -0,0 +1,240 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package route
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/movement"
+	"sort"
+	"testing"
+)
+
+// grid returns a board of the terrain, with hexes labelled by row and
+// column, like "R2C1".
+func grid(rows, cols int, terrain model.TERRAIN) *model.MAP {
+	board := &model.MAP{Hexes: make(map[string]*model.HEX)}
+	for row := 1; row <= rows; row++ {
+		for col := 1; col <= cols; col++ {
+			hex := &model.HEX{Row: row, Column: col, Label: fmt.Sprintf("R%dC%d", row, col), Terrain: terrain}
+			board.Hexes[fmt.Sprintf("%02d%03d", row, col)] = hex
+			board.Sorted = append(board.Sorted, hex)
+		}
+	}
+	sort.Sort(board.Sorted)
+	return board
+}
+
+// engine returns a movement engine for the board using the default costs.
+func engine(t *testing.T, board *model.MAP) *movement.ENGINE {
+	t.Helper()
+	table, err := movement.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return movement.New(board, table)
+}
+
+// fixture returns a small board of desert, three rows by five columns.
+// A road runs east along row 2. Row 3 has a mountain at column 3 with
+// a road leading into it from the west, and row 1 has sea at column 3.
+func fixture(t *testing.T) (*model.MAP, *movement.ENGINE) {
+	t.Helper()
+	board := grid(3, 5, model.Desert)
+	board.At(3, 3).Terrain = model.Mountain
+	board.At(1, 3).Terrain = model.Sea
+	for col := 1; col < 5; col++ {
+		board.SetTrans(board.At(2, col), model.DirE, model.Road)
+	}
+	board.SetTrans(board.At(3, 2), model.DirE, model.Road)
+	return board, engine(t, board)
+}
+
+// wall puts an escarpment, which motorized units may not cross, on
+// every hexside between columns 2 and 3 except those between hexes in
+// the rows left open.
+func wall(board *model.MAP, open ...int) {
+	gap := make(map[int]bool)
+	for _, row := range open {
+		gap[row] = true
+	}
+	for _, hex := range board.Sorted {
+		for _, d := range model.Directions {
+			neighbor := board.Neighbor(hex, d)
+			if neighbor == nil || (hex.Column <= 2) == (neighbor.Column <= 2) {
+				continue
+			} else if gap[hex.Row] && gap[neighbor.Row] {
+				continue
+			}
+			hex.Side(d).Elevation = model.UpEscarpment
+		}
+	}
+}
+
+func TestShortest(t *testing.T) {
+	board, engine := fixture(t)
+	for _, tc := range []struct {
+		class    model.CLASS
+		from, to string
+		want     []string
+		total    float64
+	}{
+		{model.ClassMotorized, "R2C1", "R2C5", []string{"R2C1", "R2C2", "R2C3", "R2C4", "R2C5"}, 2},
+		{model.ClassFoot, "R2C1", "R2C5", []string{"R2C1", "R2C2", "R2C3", "R2C4", "R2C5"}, 4},
+		{model.ClassFoot, "R3C2", "R3C3", []string{"R3C2", "R3C3"}, 1},
+		{model.ClassMotorized, "R3C2", "R3C3", nil, 0},
+		{model.ClassFoot, "R2C3", "R1C3", nil, 0},
+	} {
+		path, err := ForClass(board, engine, tc.class).Shortest(board.Lookup(tc.from), board.Lookup(tc.to))
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%s %s to %s: want no path, got %v", tc.class, tc.from, tc.to, labels(path.Hexes))
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s %s to %s: unexpected error %v", tc.class, tc.from, tc.to, err)
+			continue
+		}
+		if got := labels(path.Hexes); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s %s to %s: want %v, got %v", tc.class, tc.from, tc.to, tc.want, got)
+		}
+		if path.Total != tc.total {
+			t.Errorf("%s %s to %s: total: want %g, got %g", tc.class, tc.from, tc.to, tc.total, path.Total)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	board, engine := fixture(t)
+	got := ForClass(board, engine, model.ClassMotorized).Reachable(board.Lookup("R2C1"), 1)
+	want := map[string]float64{"R2C1": 0, "R2C2": 0.5, "R2C3": 1}
+	if len(got) != len(want) {
+		t.Errorf("reachable: want %d hexes, got %d", len(want), len(got))
+	}
+	for hex, cost := range got {
+		if c, ok := want[hex.Label]; !ok || c != cost {
+			t.Errorf("reachable: %s: want %g, got %g", hex.Label, c, cost)
+		}
+	}
+}
+
+// labels returns the labels of the hexes.
+func labels(hexes []*model.HEX) (labels []string) {
+	for _, hex := range hexes {
+		labels = append(labels, hex.Label)
+	}
+	return labels
+}
+
+func TestDetour(t *testing.T) {
+	board := grid(3, 5, model.Clear)
+	wall(board, 3)
+	search := ForClass(board, engine(t, board), model.ClassMotorized)
+	path, err := search.Shortest(board.Lookup("R1C1"), board.Lookup("R1C5"))
+	if err != nil {
+		t.Fatalf("detour: unexpected error %v", err)
+	}
+	crossed := ""
+	for i := 1; i < len(path.Hexes); i++ {
+		from, to := path.Hexes[i-1], path.Hexes[i]
+		if _, ok := board.Adjacent(from, to); !ok {
+			t.Errorf("detour: %s is not adjacent to %s", to.Label, from.Label)
+		}
+		if (from.Column <= 2) != (to.Column <= 2) {
+			crossed = from.Label + " " + to.Label
+		}
+	}
+	if crossed != "R3C2 R3C3" {
+		t.Errorf("detour: want to cross the wall at R3C2 R3C3, got %q in %v", crossed, labels(path.Hexes))
+	}
+	if direct := board.Lookup("R1C1").Distance(board.Lookup("R1C5")); path.Total <= float64(direct) {
+		t.Errorf("detour: want more than the direct cost %d, got %g", direct, path.Total)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	board := grid(3, 5, model.Clear)
+	wall(board)
+	search := ForClass(board, engine(t, board), model.ClassMotorized)
+	if path, err := search.Shortest(board.Lookup("R1C1"), board.Lookup("R1C5")); err == nil {
+		t.Errorf("walled off: want error, got %v", labels(path.Hexes))
+	}
+	for hex := range search.Reachable(board.Lookup("R1C1"), 100) {
+		if hex.Column > 2 {
+			t.Errorf("walled off: %s should not be reachable", hex.Label)
+		}
+	}
+	// foot units climb the escarpment
+	if _, err := ForClass(board, engine(t, board), model.ClassFoot).Shortest(board.Lookup("R1C1"), board.Lookup("R1C5")); err != nil {
+		t.Errorf("foot: unexpected error %v", err)
+	}
+}
+
+// TestAgreement checks that A* finds paths that cost the same as the
+// cheapest found by Dijkstra's search, which Reachable and a search
+// with no estimate both are.
+func TestAgreement(t *testing.T) {
+	terrain := []model.TERRAIN{model.Clear, model.Desert, model.Rough, model.RockGravel, model.Swamp, model.Mountain}
+	board := grid(8, 10, model.Clear)
+	for _, hex := range board.Sorted {
+		hex.Terrain = terrain[(hex.Row*7+hex.Column*3)%len(terrain)]
+	}
+	for col := 1; col < 10; col++ {
+		board.SetTrans(board.At(4, col), model.DirE, model.Road)
+	}
+	for row := 1; row < 8; row++ {
+		board.SetTrans(board.At(row, 5), model.DirNE, model.Track)
+	}
+	wall(board, 2, 3, 6)
+	e := engine(t, board)
+
+	for _, class := range model.Classes {
+		astar := ForClass(board, e, class)
+		dijkstra := New(board, ClassCost(e, class), 0)
+		for _, from := range []*model.HEX{board.At(1, 1), board.At(4, 5), board.At(8, 10)} {
+			reach := astar.Reachable(from, 1000)
+			for _, to := range board.Sorted {
+				want, ok := reach[to]
+				path, err := astar.Shortest(from, to)
+				if !ok {
+					if err == nil {
+						t.Errorf("%s %s to %s: want no path, got %g", class, from.Label, to.Label, path.Total)
+					}
+					continue
+				} else if err != nil {
+					t.Errorf("%s %s to %s: unexpected error %v", class, from.Label, to.Label, err)
+					continue
+				}
+				if path.Total != want {
+					t.Errorf("%s %s to %s: A* cost %g, Reachable cost %g", class, from.Label, to.Label, path.Total, want)
+				}
+				if other, err := dijkstra.Shortest(from, to); err != nil || other.Total != path.Total {
+					t.Errorf("%s %s to %s: A* cost %g, Dijkstra %v %v", class, from.Label, to.Label, path.Total, other, err)
+				}
+				sum := 0.0
+				for _, c := range path.Costs {
+					sum += c
+				}
+				if sum != path.Total {
+					t.Errorf("%s %s to %s: costs add to %g, total %g", class, from.Label, to.Label, sum, path.Total)
+				}
+			}
+		}
+	}
+}
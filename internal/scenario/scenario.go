@@ -0,0 +1,307 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package scenario loads and validates scenario definitions.
+//
+// A scenario is a JSON file that names the board and counter manifest,
+// the game-turns the scenario runs, where each unit starts, when and
+// where reinforcements arrive, the supply dumps on the map at the start,
+// the victory conditions for each side, and the optional rules in play:
+//
+//	{
+//	  "name": "Operation Compass",
+//	  "board": "board.json",
+//	  "counters": "counters.json",
+//	  "start": 1, "end": 12,
+//	  "weather": "normal",
+//	  "players": [{"side": "axis", "sources": ["A0129"], "railhead": "A0129"}, {"side": "commonwealth"}],
+//	  "deployments": [{"unit": "21pz/5pzrgt", "hex": "C4708"}],
+//	  "reinforcements": [{"turn": 3, "unit": "7armd/4armdbde", "hex": "E2010"}],
+//	  "dumps": [{"hex": "C4708", "side": "axis", "stock": {"fuel": 40, "ammo": 20}}],
+//	  "victory": [{"side": "commonwealth", "hold": ["C4708"]}],
+//	  "options": ["water"]
+//	}
+//
+// File names are relative to the scenario file.
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/logistics"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/rail"
+	"github.com/mdhender/tcfna/internal/stacking"
+	"github.com/mdhender/tcfna/internal/turn"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Options is the list of optional rules that a scenario may enable.
+var Options = []string{
+	"air",       // air segments and missions
+	"breakdown", // vehicle breakdown
+	"flimsies",  // fuel evaporation, worse for Commonwealth flimsies than for jerricans
+	"naval",     // naval convoys
+	"water",     // water consumption and evaporation
+}
+
+// SCENARIO is the starting position and schedule for a game.
+type SCENARIO struct {
+	Name           string                 `json:"name"`
+	Board          string                 `json:"board"`
+	Counters       string                 `json:"counters"`
+	Start          int                    `json:"start"` // first game-turn
+	End            int                    `json:"end"`   // last game-turn
+	Weather        model.WEATHER          `json:"weather"`
+	Players        []*model.PLAYER        `json:"players"`
+	Deployments    []*DEPLOYMENT          `json:"deployments"`
+	Reinforcements []*model.REINFORCEMENT `json:"reinforcements,omitempty"`
+	Dumps          []*model.DUMP          `json:"dumps,omitempty"`
+	Victory        []*model.VICTORY       `json:"victory,omitempty"`
+	Options        []string               `json:"options,omitempty"`
+}
+
+// DEPLOYMENT places a unit on the map at the start of the scenario.
+type DEPLOYMENT struct {
+	Unit string `json:"unit"` // id of the unit
+	Hex  string `json:"hex"`  // label of the starting hex
+}
+
+// Load reads a scenario file.
+// The board and counter file names are resolved relative to the scenario.
+func Load(name string) (*SCENARIO, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &SCENARIO{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if s.Board == "" {
+		return nil, fmt.Errorf("%s: missing board", name)
+	} else if s.Counters == "" {
+		return nil, fmt.Errorf("%s: missing counters", name)
+	}
+	if !filepath.IsAbs(s.Board) {
+		s.Board = filepath.Join(filepath.Dir(name), s.Board)
+	}
+	if !filepath.IsAbs(s.Counters) {
+		s.Counters = filepath.Join(filepath.Dir(name), s.Counters)
+	}
+	return s, nil
+}
+
+// Validate checks the scenario against the board and the counter manifest.
+// Every hex label must be on the board and every unit must be in the
+// manifest. It reports every problem it finds, not just the first.
+func (s *SCENARIO) Validate(board *model.MAP, units model.UNITS) error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// hex returns an error message if the label isn't a land hex on the board
+	hex := func(label string) string {
+		if h := board.Lookup(label); h == nil {
+			return fmt.Sprintf("unknown hex %q", label)
+		} else if h.Terrain.Attributes().Sea {
+			return fmt.Sprintf("hex %q is a sea hex", label)
+		}
+		return ""
+	}
+
+	if s.Start < 1 {
+		problem("start: must be at least 1")
+	}
+	if s.End < s.Start {
+		problem("end: must not be before start")
+	}
+
+	network := rail.Network(board)
+	sides := make(map[model.SIDE]bool)
+	for n, p := range s.Players {
+		if p.Side == model.NoSide {
+			problem("players: %d: missing side", n+1)
+		} else if sides[p.Side] {
+			problem("players: %d: duplicate side %q", n+1, p.Side)
+		}
+		sides[p.Side] = true
+		for _, label := range p.Sources {
+			if msg := hex(label); msg != "" {
+				problem("players: %d: source: %s", n+1, msg)
+			}
+		}
+		if p.Railhead != "" {
+			if msg := hex(p.Railhead); msg != "" {
+				problem("players: %d: railhead: %s", n+1, msg)
+			} else if !network.Has(board.Lookup(p.Railhead)) {
+				problem("players: %d: railhead: no railroad in %s", n+1, p.Railhead)
+			}
+		}
+	}
+
+	placed := make(map[string]string)
+	for n, d := range s.Deployments {
+		if units.ById(d.Unit) == nil {
+			problem("deployments: %d: unknown unit %q", n+1, d.Unit)
+		} else if where, ok := placed[d.Unit]; ok {
+			problem("deployments: %d: unit %q is already %s", n+1, d.Unit, where)
+		}
+		if msg := hex(d.Hex); msg != "" {
+			problem("deployments: %d: unit %q: %s", n+1, d.Unit, msg)
+		}
+		placed[d.Unit] = "deployed in " + d.Hex
+	}
+
+	for n, r := range s.Reinforcements {
+		if units.ById(r.Unit) == nil {
+			problem("reinforcements: %d: unknown unit %q", n+1, r.Unit)
+		} else if where, ok := placed[r.Unit]; ok {
+			problem("reinforcements: %d: unit %q is already %s", n+1, r.Unit, where)
+		}
+		if msg := hex(r.Hex); msg != "" {
+			problem("reinforcements: %d: unit %q: %s", n+1, r.Unit, msg)
+		}
+		if r.Turn <= s.Start || s.End < r.Turn {
+			problem("reinforcements: %d: unit %q: turn %d is not after the start and before the end", n+1, r.Unit, r.Turn)
+		}
+		placed[r.Unit] = fmt.Sprintf("arriving on turn %d", r.Turn)
+	}
+
+	for n, v := range s.Victory {
+		if v.Side == model.NoSide {
+			problem("victory: %d: missing side", n+1)
+		}
+		for _, label := range v.Hold {
+			if msg := hex(label); msg != "" {
+				problem("victory: %d: %s", n+1, msg)
+			}
+		}
+	}
+
+	dumps := make(map[string]bool)
+	for n, d := range s.Dumps {
+		if msg := hex(d.Hex); msg != "" {
+			problem("dumps: %d: %s", n+1, msg)
+		} else if dumps[d.Hex] {
+			problem("dumps: %d: duplicate dump in %s", n+1, d.Hex)
+		}
+		dumps[d.Hex] = true
+		if d.Side == model.NoSide {
+			problem("dumps: %d: missing side", n+1)
+		}
+		for kind, amount := range d.Stock {
+			if amount < 0 {
+				problem("dumps: %d: %s must not be negative", n+1, kind)
+			}
+		}
+	}
+
+	for _, option := range s.Options {
+		known := false
+		for _, o := range Options {
+			known = known || o == option
+		}
+		if !known {
+			problem("options: unknown option %q", option)
+		}
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("scenario %q:\n\t%s", s.Name, strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// NewGame returns the starting position for the scenario.
+// The scenario should be validated first.
+// Units that are neither deployed nor arriving as reinforcements are
+// left off the map. It returns an error if the deployments over-stack
+// any hex.
+func (s *SCENARIO) NewGame(board *model.MAP, units model.UNITS) (*model.GAME, error) {
+	if err := s.Validate(board, units); err != nil {
+		return nil, err
+	}
+
+	g := &model.GAME{
+		Name:           s.Name,
+		Board:          s.Board,
+		Map:            board,
+		Turn:           s.Start,
+		Phase:          turn.Sequence[0].Phase.Name,
+		LastTurn:       s.End,
+		Weather:        s.Weather,
+		Players:        s.Players,
+		Units:          units,
+		Reinforcements: s.Reinforcements,
+		Victory:        s.Victory,
+	}
+	for _, option := range s.Options {
+		if g.Options == nil {
+			g.Options = make(map[string]bool)
+		}
+		g.Options[option] = true
+	}
+
+	// the manifest may give units a hex; the scenario decides where they start
+	for _, u := range units {
+		u.Hex = ""
+	}
+	for _, d := range s.Deployments {
+		units.ById(d.Unit).Hex = d.Hex
+	}
+
+	// opening stocks go through the ledger so that every balance can be traced
+	ledger := logistics.New(g)
+	for _, u := range units {
+		stock, tank := u.Stock, u.Tank
+		u.Stock, u.Tank = nil, nil
+		for _, kind := range model.Supplies {
+			if stock[kind] != 0 {
+				_ = ledger.Receive(u.Id, kind, stock[kind], "opening stock")
+			}
+		}
+		if tank[model.Fuel] != 0 {
+			_ = ledger.Receive(model.TankId(u.Id), model.Fuel, tank[model.Fuel], "opening stock")
+		}
+	}
+	for _, d := range s.Dumps {
+		_, _ = ledger.Build(d.Side, d.Hex)
+		for _, kind := range model.Supplies {
+			if d.Stock[kind] != 0 {
+				_ = ledger.Receive(model.DumpId(d.Hex), kind, d.Stock[kind], "opening stock")
+			}
+		}
+	}
+
+	if violations := stacking.Check(g, stacking.Default); len(violations) != 0 {
+		var problems []string
+		for _, v := range violations {
+			problems = append(problems, fmt.Sprintf("deployments: %s", v))
+		}
+		return nil, fmt.Errorf("scenario %q:\n\t%s", s.Name, strings.Join(problems, "\n\t"))
+	}
+
+	return g, nil
+}
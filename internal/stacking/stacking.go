@@ -0,0 +1,156 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package stacking checks the number of units in each hex.
+//
+// Each unit counts a number of stacking points that depends on its size.
+// The points a side may have in a hex depend on the terrain, with more
+// room in major cities and ports. The two sides never share a hex, so
+// each side is checked on its own.
+package stacking
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+)
+
+// RULES are the stacking points and limits.
+type RULES struct {
+	// Points is the stacking points for a unit of each size.
+	// Sizes that are missing do not count.
+	Points map[model.SIZE]int
+	// Exempt lists unit types that do not count toward stacking.
+	Exempt map[model.UNITTYPE]bool
+	// Limit is the most points a side may have in a hex with the terrain.
+	// Terrain that is missing uses Default. A limit of zero is no limit.
+	Limit   map[model.TERRAIN]int
+	Default int
+	// MajorCity and Port are added to the limit for hexes with the feature.
+	MajorCity int
+	Port      int
+}
+
+// Default is the standard stacking rules.
+var Default = RULES{
+	Points: map[model.SIZE]int{
+		model.Company:   1,
+		model.Battalion: 2,
+		model.Regiment:  4,
+		model.Brigade:   6,
+		model.Division:  10,
+		model.Corps:     10,
+	},
+	Exempt: map[model.UNITTYPE]bool{
+		model.Headquarters: true,
+	},
+	Limit: map[model.TERRAIN]int{
+		model.Delta:           10,
+		model.HeavyVegetation: 8,
+		model.Mountain:        6,
+		model.Rough:           8,
+		model.SaltMarsh:       8,
+		model.Swamp:           6,
+	},
+	Default:   12,
+	MajorCity: 8,
+	Port:      4,
+}
+
+// points returns the stacking points for the unit.
+func (r RULES) points(u *model.UNIT) int {
+	if r.Exempt[u.Type] {
+		return 0
+	}
+	return r.Points[u.Size]
+}
+
+// limit returns the most points a side may have in the hex,
+// or zero if there is no limit.
+func (r RULES) limit(hex *model.HEX) int {
+	limit, ok := r.Limit[hex.Terrain]
+	if !ok {
+		limit = r.Default
+	}
+	if limit == 0 {
+		return 0
+	}
+	if hex.Features.Has(model.MajorCity) {
+		limit += r.MajorCity
+	}
+	if hex.Features.Has(model.Port) {
+		limit += r.Port
+	}
+	return limit
+}
+
+// Hex returns a violation if the side is over-stacked in the hex,
+// or nil if it is not.
+func Hex(g *model.GAME, rules RULES, hex *model.HEX, side model.SIDE) *model.VIOLATION {
+	limit := rules.limit(hex)
+	if limit == 0 {
+		return nil
+	}
+	points := 0
+	for _, u := range g.UnitsAt(hex.Label) {
+		if u.Side() == side {
+			points += rules.points(u)
+		}
+	}
+	if points <= limit {
+		return nil
+	}
+	return &model.VIOLATION{
+		Rule:    "stacking",
+		Hex:     hex.Label,
+		Side:    side,
+		Message: fmt.Sprintf("%d stacking points, limit is %d", points, limit),
+	}
+}
+
+// Check returns every over-stacked hex on the board, sorted by hex and side.
+func Check(g *model.GAME, rules RULES) (violations []*model.VIOLATION) {
+	type key struct {
+		hex  *model.HEX
+		side model.SIDE
+	}
+	seen := make(map[key]bool)
+	var keys []key
+	for _, u := range g.Units {
+		if hex := g.Map.Lookup(u.Hex); hex != nil && !seen[key{hex, u.Side()}] {
+			seen[key{hex, u.Side()}] = true
+			keys = append(keys, key{hex, u.Side()})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].hex != keys[j].hex {
+			a, b := keys[i].hex, keys[j].hex
+			if a.Row != b.Row {
+				return a.Row < b.Row
+			}
+			return a.Column < b.Column
+		}
+		return keys[i].side < keys[j].side
+	})
+	for _, k := range keys {
+		if v := Hex(g, rules, k.hex, k.side); v != nil {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
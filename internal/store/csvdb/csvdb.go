@@ -172,32 +172,47 @@ func Convert(name string) (*model.MAP, error) {
 			Column:     sectionCol,
 			Name:       r.name,
 			Habitation: r.habitation,
-			Terrain:    r.terrain,
 		}
-		hex.Sides.NE.Elevation = r.hsElevationNE
-		hex.Sides.NE.Trans = r.hsTransNE
-		hex.Sides.NE.Water = r.hsWaterNE
-		hex.Sides.E.Elevation = r.hsElevationE
-		hex.Sides.E.Trans = r.hsTransE
-		hex.Sides.E.Water = r.hsWaterE
-		hex.Sides.SE.Elevation = r.hsElevationSE
-		hex.Sides.SE.Trans = r.hsTransSE
-		hex.Sides.SE.Water = r.hsWaterSE
-		hex.Sides.SW.Elevation = r.hsElevationSW
-		hex.Sides.SW.Trans = r.hsTransSW
-		hex.Sides.SW.Water = r.hsWaterSW
-		hex.Sides.W.Elevation = r.hsElevationW
-		hex.Sides.W.Trans = r.hsTransW
-		hex.Sides.W.Water = r.hsWaterW
-		hex.Sides.NW.Elevation = r.hsElevationNW
-		hex.Sides.NW.Trans = r.hsTransNW
-		hex.Sides.NW.Water = r.hsWaterNW
+		// the spreadsheet is hand-edited, so warn about names we don't
+		// recognize instead of rejecting the board. the raw habitation
+		// is kept on the hex.
+		if hex.Terrain, err = model.ParseTerrain(r.terrain); err != nil {
+			log.Printf("[csvdb] hex %s: %v: skipped\n", hex.Label, err)
+		}
+		if hex.Features, err = model.ParseFeatures(r.habitation); err != nil {
+			log.Printf("[csvdb] hex %s: habitation: %v: skipped\n", hex.Label, err)
+		}
+
+		// decode the hexside codes, rejecting any that we don't recognize
+		for _, side := range []struct {
+			d                       model.DIRECTION
+			elevation, trans, water string
+		}{
+			{model.DirNE, r.hsElevationNE, r.hsTransNE, r.hsWaterNE},
+			{model.DirE, r.hsElevationE, r.hsTransE, r.hsWaterE},
+			{model.DirSE, r.hsElevationSE, r.hsTransSE, r.hsWaterSE},
+			{model.DirSW, r.hsElevationSW, r.hsTransSW, r.hsWaterSW},
+			{model.DirW, r.hsElevationW, r.hsTransW, r.hsWaterW},
+			{model.DirNW, r.hsElevationNW, r.hsTransNW, r.hsWaterNW},
+		} {
+			hs := hex.Side(side.d)
+			if hs.Elevation, err = model.ParseElevation(side.elevation); err != nil {
+				return nil, fmt.Errorf("hex %s: %s side: %w", hex.Label, side.d, err)
+			}
+			if hs.Trans, err = model.ParseTrans(side.trans); err != nil {
+				return nil, fmt.Errorf("hex %s: %s side: %w", hex.Label, side.d, err)
+			}
+			if hs.Water, err = model.ParseWater(side.water); err != nil {
+				return nil, fmt.Errorf("hex %s: %s side: %w", hex.Label, side.d, err)
+			}
+		}
 
 		m.Hexes[hex.Id] = hex
 		m.Sorted = append(m.Sorted, hex)
 	}
 
 	sort.Sort(m.Sorted)
+	m.Index()
 
 	return m, nil
 }
@@ -0,0 +1,104 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// LoadGame loads a saved game and the board that it refers to.
+func LoadGame(name string) (*model.GAME, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &model.GAME{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(g); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if g.Board == "" {
+		return nil, fmt.Errorf("%s: missing board", name)
+	}
+	// from here on, the board is relative to the current directory
+	if !filepath.IsAbs(g.Board) {
+		g.Board = filepath.Join(filepath.Dir(name), g.Board)
+	}
+	if g.Map, err = Convert(g.Board); err != nil {
+		return nil, fmt.Errorf("%s: board: %w", name, err)
+	}
+
+	// every unit on the map must be in a hex that exists
+	for _, u := range g.Units {
+		if u.Hex != "" && g.Map.Lookup(u.Hex) == nil {
+			return nil, fmt.Errorf("%s: unit %s: unknown hex %q", name, u.Id, u.Hex)
+		}
+	}
+
+	// finished railroad construction changes the board
+	for _, w := range g.Rail {
+		hex := g.Map.Lookup(w.Hex)
+		if hex == nil {
+			return nil, fmt.Errorf("%s: rail: unknown hex %q", name, w.Hex)
+		} else if w.Done {
+			g.Map.SetTrans(hex, w.Direction, model.Railroad)
+		}
+	}
+
+	return g, nil
+}
+
+// WriteGame saves the game in the format that LoadGame reads.
+// The board is not written; the game refers to it by name, relative
+// to the game file so that the two can be moved together.
+func WriteGame(name string, g *model.GAME) error {
+	out := *g
+	out.Board = relativeTo(g.Board, name)
+	b, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
+
+// relativeTo returns the name of a file relative to the directory
+// that another file is in.
+func relativeTo(name, other string) string {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return name
+	}
+	dir, err := filepath.Abs(filepath.Dir(other))
+	if err != nil {
+		return name
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return abs
+	}
+	return rel
+}
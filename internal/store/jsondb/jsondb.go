@@ -0,0 +1,139 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"io/ioutil"
+	"log"
+	"sort"
+)
+
+// document is the structure of the board file written by the json export.
+type document struct {
+	Data []json.RawMessage `json:"data"`
+}
+
+// Convert loads a board file created by the json export.
+// It rebuilds both the map of hexes and the sorted list.
+func Convert(name string) (*model.MAP, error) {
+	// load the JSON file from disk
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode the envelope first so that we can report errors on each hex.
+	var doc document
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	// log the raw number of records as a first sanity check
+	log.Printf("[jsondb] read %d records\n", len(doc.Data))
+	if len(doc.Data) == 0 {
+		return nil, fmt.Errorf("input file has no hexes")
+	}
+
+	m := &model.MAP{Hexes: make(map[string]*model.HEX)}
+	labels := make(map[string]*model.HEX)
+	for n, raw := range doc.Data {
+		hex, err := decodeHex(raw)
+		if err != nil {
+			return nil, fmt.Errorf("hex %s: %w", hexLabel(raw, n), err)
+		}
+
+		// the id must agree with the row and column
+		if hex.Id == "" {
+			return nil, fmt.Errorf("hex %s: missing id", hexLabel(raw, n))
+		} else if id := fmt.Sprintf("%02d%03d", hex.Row, hex.Column); hex.Id != id {
+			return nil, fmt.Errorf("hex %s: id %q does not match row %d column %d", hexLabel(raw, n), hex.Id, hex.Row, hex.Column)
+		}
+		// boards exported before features were added only have the raw habitation,
+		// which may hold names that are not features
+		if hex.Features == 0 && hex.Habitation != "" {
+			if hex.Features, err = model.ParseFeatures(hex.Habitation); err != nil {
+				log.Printf("[jsondb] hex %s: habitation: %v: skipped\n", hexLabel(raw, n), err)
+			}
+		}
+
+		if other, ok := m.Hexes[hex.Id]; ok {
+			return nil, fmt.Errorf("hex %s: duplicate id %q (also used by %s)", hexLabel(raw, n), hex.Id, other.Label)
+		}
+		if hex.Label != "" {
+			if other, ok := labels[hex.Label]; ok {
+				return nil, fmt.Errorf("hex %s: duplicate label (also used by id %q)", hex.Label, other.Id)
+			}
+			labels[hex.Label] = hex
+		}
+
+		m.Hexes[hex.Id] = hex
+		m.Sorted = append(m.Sorted, hex)
+	}
+
+	sort.Sort(m.Sorted)
+	m.Index()
+
+	return m, nil
+}
+
+// decodeHex decodes a single hex, rejecting any fields that the model does not know about.
+func decodeHex(raw json.RawMessage) (*model.HEX, error) {
+	hex := &model.HEX{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(hex); err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
+// hexLabel does its best to find a label for error messages.
+// It falls back to the id or the position in the input.
+func hexLabel(raw json.RawMessage, n int) string {
+	var hex struct {
+		Id    string `json:"id"`
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(raw, &hex); err == nil {
+		if hex.Label != "" {
+			return hex.Label
+		} else if hex.Id != "" {
+			return fmt.Sprintf("id %q", hex.Id)
+		}
+	}
+	return fmt.Sprintf("#%d", n+1)
+}
+
+// Write saves the board in the format that Convert reads.
+func Write(name string, m *model.MAP) error {
+	data := struct {
+		Data model.HEXES `json:"data"`
+	}{Data: m.Sorted}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
@@ -0,0 +1,147 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"io/ioutil"
+	"log"
+	"sort"
+)
+
+// LoadCounters loads a counter manifest.
+// The manifest has the same {"data": [...]} layout as the board file,
+// with one entry per unit.
+func LoadCounters(name string) (model.UNITS, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	log.Printf("[jsondb] read %d counters\n", len(doc.Data))
+
+	var units model.UNITS
+	ids := make(map[string]*model.UNIT)
+	for n, raw := range doc.Data {
+		unit := &model.UNIT{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(unit); err != nil {
+			return nil, fmt.Errorf("unit %s: %w", unitLabel(raw, n), err)
+		}
+		if err := checkUnit(unit); err != nil {
+			return nil, fmt.Errorf("unit %s: %w", unitLabel(raw, n), err)
+		} else if _, ok := ids[unit.Id]; ok {
+			return nil, fmt.Errorf("unit %s: duplicate id", unit.Id)
+		}
+		ids[unit.Id] = unit
+		units = append(units, unit)
+	}
+
+	// the formation hierarchy must refer to units in the manifest
+	for _, unit := range units {
+		if unit.Parent == "" {
+			continue
+		}
+		parent, ok := ids[unit.Parent]
+		if !ok {
+			return nil, fmt.Errorf("unit %s: unknown parent %q", unit.Id, unit.Parent)
+		} else if parent.Side() != unit.Side() {
+			return nil, fmt.Errorf("unit %s: parent %q is on the other side", unit.Id, unit.Parent)
+		}
+		for p, seen := parent, map[string]bool{unit.Id: true}; p != nil; p = ids[p.Parent] {
+			if seen[p.Id] {
+				return nil, fmt.Errorf("unit %s: formation hierarchy loops through %q", unit.Id, p.Id)
+			}
+			seen[p.Id] = true
+		}
+	}
+
+	sort.Sort(units)
+
+	return units, nil
+}
+
+// WriteCounters saves units in the format that LoadCounters reads.
+func WriteCounters(name string, units model.UNITS) error {
+	data := struct {
+		Data model.UNITS `json:"data"`
+	}{Data: units}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
+
+// checkUnit verifies that the counter has the fields that the engine needs.
+func checkUnit(unit *model.UNIT) error {
+	if unit.Id == "" {
+		return fmt.Errorf("missing id")
+	} else if unit.Nationality == model.NoNationality {
+		return fmt.Errorf("missing nationality")
+	} else if unit.Type == model.NoUnitType {
+		return fmt.Errorf("missing type")
+	} else if unit.Size == model.NoSize {
+		return fmt.Errorf("missing size")
+	} else if unit.Class == model.NoClass {
+		return fmt.Errorf("missing class")
+	} else if unit.TOE < 0 {
+		return fmt.Errorf("toe must not be negative")
+	} else if unit.CPA < 0 {
+		return fmt.Errorf("cpa must not be negative")
+	} else if unit.CP < 0 {
+		return fmt.Errorf("cp must not be negative")
+	}
+	for kind, amount := range unit.Stock {
+		if amount < 0 {
+			return fmt.Errorf("stock: %s must not be negative", kind)
+		}
+	}
+	for kind, amount := range unit.Tank {
+		if kind != model.Fuel {
+			return fmt.Errorf("tank: must only hold fuel")
+		} else if unit.Type != model.Truck {
+			return fmt.Errorf("tank: only truck units have tanks")
+		} else if amount < 0 {
+			return fmt.Errorf("tank: fuel must not be negative")
+		}
+	}
+	return nil
+}
+
+// unitLabel does its best to find an id for error messages.
+func unitLabel(raw json.RawMessage, n int) string {
+	var unit struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &unit); err == nil && unit.Id != "" {
+		return unit.Id
+	}
+	return fmt.Sprintf("#%d", n+1)
+}
@@ -21,11 +21,19 @@ package memory
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"log"
 	"time"
 )
 
+// BoardAsHTML returns a page showing just the board.
 func (ds *STORE) BoardAsHTML() []byte {
+	return ds.PageAsHTML("SVG Test", nil, nil)
+}
+
+// PageAsHTML returns a page showing the board with the overlay applied,
+// followed by the body. The body must be valid HTML. The overlay may be nil.
+func (ds *STORE) PageAsHTML(title string, overlay OVERLAY, body []byte) []byte {
 	start := time.Now()
 
 	// create the svg for the board
@@ -34,13 +42,14 @@ func (ds *STORE) BoardAsHTML() []byte {
 	_, _ = fmt.Fprintln(b, `<html lang="en">`)
 	_, _ = fmt.Fprintln(b, `<head>`)
 	_, _ = fmt.Fprintln(b, `<meta charset="utf-8">`)
-	_, _ = fmt.Fprintln(b, `<title>SVG Test</title>`)
+	_, _ = fmt.Fprintf(b, "<title>%s</title>\n", html.EscapeString(title))
 	//_, _ = fmt.Fprintln(b, `<style>div.scroll {background-color: #fed9ff;width: 95%;height: 95%;overflow: auto;text-align: justify;padding: 1%;}</style>`)
 	_, _ = fmt.Fprintln(b, `</head>`)
 	_, _ = fmt.Fprintln(b, `<body>`)
 	//_, _ = fmt.Fprintln(b, `<div class="scroll">`)
-	_, _ = fmt.Fprintln(b, ds.BoardAsSVG().String())
+	_, _ = fmt.Fprintln(b, ds.BoardAsOverlaySVG(overlay).String())
 	//_, _ = fmt.Fprintln(b, `</div>`)
+	_, _ = b.Write(body)
 	_, _ = fmt.Fprintln(b, "</body>")
 	_, _ = fmt.Fprintln(b, "</html>")
 
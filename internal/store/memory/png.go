@@ -20,6 +20,7 @@ package memory
 
 import (
 	"github.com/fogleman/gg"
+	"github.com/mdhender/tcfna/internal/model"
 	"log"
 	"math"
 	"path/filepath"
@@ -27,23 +28,25 @@ import (
 )
 
 func (ds *STORE) BoardAsImage(save bool) {
+	dc := ds.boardImage()
+	if save {
+		start := time.Now()
+		_ = dc.SavePNG(filepath.Join("..", "data", "board.png"))
+		log.Printf("[png] elapsed time %+v\n", time.Now().Sub(start))
+	}
+}
+
+// SaveBoardImage writes the board as a PNG file.
+func (ds *STORE) SaveBoardImage(name string) error {
+	return ds.boardImage().SavePNG(name)
+}
+
+// boardImage draws the board, and any units, on a new context.
+func (ds *STORE) boardImage() *gg.Context {
 	start := time.Now()
 
-	// default background fill based on terrain
-	clear := hslToRgb(53, 1.0, 0.94)       // hsl(53, 100%, 94%)
-	delta := hslToRgb(74, 0.48, 0.76)      // hsl(74, 48%, 76%)
-	desert := hslToRgb(48, 0.81, 0.66)     // hsl(48, 81%, 66%)
-	gravel := hslToRgb(49, 0.79, 0.89)     // hsl(49, 79%, 89%)
-	mountain := hslToRgb(47, 0.40, 0.63)   // hsl(47, 40%, 63%)
-	ocean := hslToRgb(193, 0.67, 0.28)     // LightBlue
-	rock := hslToRgb(49, 0.79, 0.89)       // hsl(49, 79%, 89%)
-	rockGravel := hslToRgb(49, 0.79, 0.89) // hsl(49, 79%, 89%)
-	rough := hslToRgb(43, 0.43, 0.77)      // hsl(43, 43%, 77%)
-	saltMarsh := hslToRgb(65, 0.85, 0.90)  // hsl(65, 85%, 90%)
-	sea := hslToRgb(198, 0.78, 0.86)       // hsl(197, 78%, 85%)
-	swamp := hslToRgb(68, 0.78, 0.93)      // hsl(68, 78%, 93%)
-	unknown := hslToRgb(39, 1.0, 0.5)      // hsl(39, 100%, 50%)
-	vegetation := hslToRgb(85, 0.56, 0.71) // hsl(85, 56%, 71%)
+	// default background fill when the terrain is unknown
+	unknown := hslToRgb(39, 1.0, 0.5) // hsl(39, 100%, 50%)
 
 	// find the min and max values for rows and columns
 	_, maxRow, _, maxCol := ds.board.Sorted.Bounds()
@@ -75,78 +78,53 @@ func (ds *STORE) BoardAsImage(save bool) {
 		dc.DrawRegularPolygon(6, x, y, radius, rotation)
 
 		// use the terrain to determine the fill for the hex
-		var fillColor HSL
-		switch hex.Terrain {
-		case "Clear":
-			fillColor = clear
-		case "Delta":
-			fillColor = delta
-		case "Desert":
-			fillColor = desert
-		case "Gravel":
-			fillColor = gravel
-		case "Mountain":
-			fillColor = mountain
-		case "Ocean":
-			fillColor = ocean
-		case "Rock":
-			fillColor = rock
-		case "Rock/Gravel":
-			fillColor = rockGravel
-		case "Rough":
-			fillColor = rough
-		case "Salt Marsh":
-			fillColor = saltMarsh
-		case "Sea":
-			fillColor = sea
-		case "Swamp":
-			fillColor = swamp
-		case "Vegetation":
-			fillColor = vegetation
-		default:
-			fillColor = unknown
+		fillColor := unknown
+		if attr, ok := model.TerrainAttributes[hex.Terrain]; ok {
+			fillColor = hslToRgb(attr.Fill.Hue, attr.Fill.Saturation, attr.Fill.Lightness)
 		}
 		dc.SetRGBA(fillColor.red, fillColor.green, fillColor.blue, a)
 		dc.FillPreserve()
 
 		dc.SetRGBA(0, 0, 0, a)
 		dc.DrawStringAnchored(hex.Label, x, y-radius/3, 0.5, 0.5)
-		if hex.Sides.NE.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.NE.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.NE.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.NE.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.NE.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.NE.Water, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.E.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.E.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.E.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.E.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.E.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.E.Water, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SE.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.SE.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SE.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.SE.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SE.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.SE.Water, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SW.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.SW.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SW.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.SW.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SW.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.SW.Water, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.SW.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.W.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.W.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.W.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.W.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.W.Water, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.NW.Elevation != "" {
-			dc.DrawStringAnchored(hex.Sides.NW.Elevation, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.NW.Trans != "" {
-			dc.DrawStringAnchored(hex.Sides.NW.Trans, x, y+radius/3, 0.5, 0.5)
-		} else if hex.Sides.NW.Water != "" {
-			dc.DrawStringAnchored(hex.Sides.NW.Water, x, y+radius/3, 0.5, 0.5)
+		if note := ds.unitNote(hex); note != "" {
+			dc.DrawStringAnchored(note, x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NE.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.NE.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NE.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.NE.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NE.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.NE.Water.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.E.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.E.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.E.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.E.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.E.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.E.Water.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SE.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.SE.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SE.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.SE.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SE.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.SE.Water.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SW.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.SW.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SW.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.SW.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SW.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.SW.Water.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.SW.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.W.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.W.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.W.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.W.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.W.Water.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NW.Elevation != model.NoElevation {
+			dc.DrawStringAnchored(hex.Sides.NW.Elevation.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NW.Trans != model.NoTrans {
+			dc.DrawStringAnchored(hex.Sides.NW.Trans.String(), x, y+radius/3, 0.5, 0.5)
+		} else if hex.Sides.NW.Water != model.NoWater {
+			dc.DrawStringAnchored(hex.Sides.NW.Water.String(), x, y+radius/3, 0.5, 0.5)
 		}
 
 		// draw a black line around the hex
@@ -158,12 +136,7 @@ func (ds *STORE) BoardAsImage(save bool) {
 	elapsed := time.Now().Sub(start)
 	log.Printf("[png] elapsed time %+v\n", elapsed)
 
-	if save {
-		_ = dc.SavePNG(filepath.Join("..", "data", "board.png"))
-
-		elapsed = time.Now().Sub(start)
-		log.Printf("[png] elapsed time %+v\n", elapsed)
-	}
+	return dc
 }
 
 type HSL struct {
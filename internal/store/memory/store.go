@@ -18,12 +18,41 @@
 
 package memory
 
-import "github.com/mdhender/tcfna/internal/model"
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+)
 
 type STORE struct {
 	board *model.MAP
+	units map[string]model.UNITS // units to draw, by hex label
 }
 
 func New(board *model.MAP) *STORE {
 	return &STORE{board: board}
 }
+
+// NewView returns a store that draws the units on the board.
+// Callers that render for one side must pass units that have already
+// been filtered for that side.
+func NewView(board *model.MAP, units model.UNITS) *STORE {
+	ds := &STORE{board: board, units: make(map[string]model.UNITS)}
+	for _, u := range units {
+		if u.Hex != "" {
+			ds.units[u.Hex] = append(ds.units[u.Hex], u)
+		}
+	}
+	return ds
+}
+
+// unitNote returns a short note naming the units in the hex.
+func (ds *STORE) unitNote(hex *model.HEX) string {
+	units := ds.units[hex.Label]
+	switch len(units) {
+	case 0:
+		return ""
+	case 1:
+		return units[0].Id
+	}
+	return fmt.Sprintf("%s +%d", units[0].Id, len(units)-1)
+}
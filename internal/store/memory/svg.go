@@ -20,12 +20,25 @@ package memory
 
 import (
 	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
 	"log"
 	"math"
 	"time"
 )
 
+// OVERLAY lets callers shade hexes and add a note to them.
+// Returning an empty fill leaves the terrain color in place.
+type OVERLAY interface {
+	Overlay(hex *model.HEX) (fill, note string)
+}
+
 func (ds *STORE) BoardAsSVG() *svg {
+	return ds.BoardAsOverlaySVG(nil)
+}
+
+// BoardAsOverlaySVG renders the board with the overlay applied on top
+// of the terrain. The overlay may be nil.
+func (ds *STORE) BoardAsOverlaySVG(overlay OVERLAY) *svg {
 	start := time.Now()
 
 	// find the min and max values for rows and columns
@@ -56,6 +69,15 @@ func (ds *STORE) BoardAsSVG() *svg {
 
 		poly := &polygon{x: x, y: y, radius: radius, label: hex.Label}
 		poly.style.fill = terrainToFillColor(hex.Terrain)
+		poly.note = ds.unitNote(hex)
+		if overlay != nil {
+			if fill, note := overlay.Overlay(hex); fill != "" {
+				poly.style.fill = fill
+				if note != "" {
+					poly.note = note
+				}
+			}
+		}
 		poly.style.stroke = "LightGrey"
 		poly.style.stroke = "Grey"
 		if poly.style.fill == poly.style.stroke {
@@ -86,6 +108,7 @@ func (p point) String() string {
 type polygon struct {
 	x, y, radius float64
 	label        string
+	note         string
 	style        struct {
 		fill        string
 		stroke      string
@@ -115,6 +138,9 @@ func (p polygon) String() string {
 	}
 	s += "></polygon>\n"
 	s += fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" fill="grey" font-size="12">%s</text>`, p.x, p.y, p.label)
+	if p.note != "" {
+		s += fmt.Sprintf("\n"+`<text x="%f" y="%f" text-anchor="middle" fill="black" font-size="10">%s</text>`, p.x, p.y+p.radius/2, p.note)
+	}
 	return s
 }
 
@@ -142,35 +168,9 @@ func (s svg) String() string {
 }
 
 // terrainToFillColor returns the background fill for a terrain type.
-func terrainToFillColor(t string) string {
-	switch t {
-	case "Clear":
-		return "hsl(53, 100%, 94%)"
-	case "Delta":
-		return "hsl(74, 48%, 76%)"
-	case "Desert":
-		return "hsl(48, 81%, 66%)"
-	case "Gravel":
-		return "hsl(49, 79%, 89%)"
-	case "Mountain":
-		return "hsl(47, 40%, 63%)"
-	case "Ocean":
-		return "LightBlue"
-	case "Rock":
-		return "hsl(49, 79%, 89%)"
-	case "Rock/Gravel":
-		return "hsl(49, 79%, 89%)"
-	case "Rough":
-		return "hsl(43, 43%, 77%)"
-	case "Salt Marsh":
-		return "hsl(65, 85%, 90%)"
-	case "Sea":
-		return "hsl(197, 78%, 85%)"
-	case "Swamp":
-		return "hsl(68, 78%, 93%)"
-	case "Vegetation":
-		return "hsl(85, 56%, 71%)"
-	default:
-		return "hsl(39, 100%, 50%)"
+func terrainToFillColor(t model.TERRAIN) string {
+	if attr, ok := model.TerrainAttributes[t]; ok {
+		return attr.Fill.String()
 	}
+	return "hsl(39, 100%, 50%)"
 }
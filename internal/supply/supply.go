@@ -0,0 +1,153 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package supply traces lines of communication from supply sources.
+package supply
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/pq"
+	"sort"
+)
+
+// RULES controls how a line of communication is traced.
+type RULES struct {
+	// Limit is the longest trace allowed, in hexes of cross-country travel.
+	Limit float64
+	// Trans is what one hex of travel counts as along a road or track.
+	// Routes that are not listed count as cross-country.
+	Trans map[model.TRANS]float64
+}
+
+// Default is the standard trace. Roads count a quarter and tracks half
+// of a cross-country hex, so a trace will follow them when it can.
+var Default = RULES{
+	Limit: 10,
+	Trans: map[model.TRANS]float64{
+		model.Road:            0.25,
+		model.RoadAndRailroad: 0.25,
+		model.Track:           0.5,
+		model.UnfinishedRoad:  0.5,
+	},
+}
+
+// STATUS is the supply status of a single hex.
+type STATUS struct {
+	InSupply bool
+	Length   float64    // weighted length of the trace
+	Hexes    int        // number of hexes in the trace
+	Source   *model.HEX // source the trace leads back to
+}
+
+// RESULT is the supply status of every hex on the board.
+type RESULT struct {
+	rules  RULES
+	status map[*model.HEX]STATUS
+}
+
+// Trace computes the supply status of every hex on the board.
+// A trace may not enter a sea hex or an enemy-controlled hex. It may
+// not cross a sea or Nile hexside except along one of the routes listed
+// in the rules, which by default include tracks as well as roads.
+// Sources in enemy-controlled hexes are ignored.
+func Trace(board *model.MAP, rules RULES, sources []*model.HEX, enemy map[*model.HEX]bool) *RESULT {
+	r := &RESULT{rules: rules, status: make(map[*model.HEX]STATUS)}
+
+	q := &pq.QUEUE{}
+	for _, source := range sources {
+		if source == nil || enemy[source] {
+			continue
+		} else if _, ok := r.status[source]; ok {
+			continue
+		}
+		r.status[source] = STATUS{InSupply: true, Source: source}
+		q.Push(source, 0)
+	}
+
+	done := map[*model.HEX]bool{}
+	for q.Len() != 0 {
+		hex := q.Pop()
+		if done[hex] {
+			continue
+		}
+		done[hex] = true
+		from := r.status[hex]
+		for _, d := range model.Directions {
+			neighbor := board.Neighbor(hex, d)
+			if neighbor == nil || done[neighbor] || enemy[neighbor] || neighbor.Terrain.Attributes().Sea {
+				continue
+			}
+			step, ok := r.step(board.Hexside(hex, d))
+			if !ok || from.Length+step > rules.Limit {
+				continue
+			}
+			if status, ok := r.status[neighbor]; ok && status.Length <= from.Length+step {
+				continue
+			}
+			r.status[neighbor] = STATUS{InSupply: true, Length: from.Length + step, Hexes: from.Hexes + 1, Source: from.Source}
+			q.Push(neighbor, from.Length+step)
+		}
+	}
+
+	return r
+}
+
+// step returns the weighted length of crossing the hexside.
+func (r *RESULT) step(side model.HEXSIDE) (float64, bool) {
+	if weight, ok := r.rules.Trans[side.Trans]; ok {
+		return weight, true
+	} else if side.Water == model.SeaHexside || side.Water == model.Nile {
+		return 0, false
+	}
+	return 1, true
+}
+
+// Status returns the supply status of the hex.
+func (r *RESULT) Status(hex *model.HEX) STATUS {
+	return r.status[hex]
+}
+
+// InSupply returns every hex that is in supply.
+func (r *RESULT) InSupply() (hexes model.HEXES) {
+	for hex, status := range r.status {
+		if status.InSupply {
+			hexes = append(hexes, hex)
+		}
+	}
+	sort.Sort(hexes)
+	return hexes
+}
+
+// Overlay implements memory.OVERLAY by shading hexes that are in supply
+// green and land hexes that are not in supply red.
+// Shorter traces are shaded darker.
+func (r *RESULT) Overlay(hex *model.HEX) (fill, note string) {
+	if hex.Terrain.Attributes().Sea {
+		return "", ""
+	}
+	status, ok := r.status[hex]
+	if !ok {
+		return model.COLOR{Hue: 0, Saturation: 0.70, Lightness: 0.75}.String(), ""
+	}
+	lightness := 0.40
+	if r.rules.Limit > 0 {
+		lightness += 0.45 * status.Length / r.rules.Limit
+	}
+	return model.COLOR{Hue: 120, Saturation: 0.60, Lightness: lightness}.String(), fmt.Sprintf("%.2f", status.Length)
+}
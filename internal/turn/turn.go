@@ -0,0 +1,249 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package turn implements the sequence of play.
+//
+// A game-turn opens with a few phases that happen once per turn, then
+// runs three operations stages, each with the same list of phases, and
+// closes with the end of turn phases. The game records the current
+// game-turn, operations stage, and phase; this package knows what comes
+// next, which player is phasing, and which orders are legal.
+package turn
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+)
+
+// Stages is the number of operations stages in a game-turn.
+const Stages = 3
+
+// PLAYER says who acts in a phase.
+type PLAYER int
+
+const (
+	Automatic PLAYER = iota // the moderator acts; players submit no orders
+	Both                    // both players submit orders
+	First                   // the player with the initiative
+	Second                  // the other player
+)
+
+// PHASE is one step in the sequence of play.
+type PHASE struct {
+	Name   string
+	Player PLAYER
+	Orders []string // order verbs that are legal in the phase
+}
+
+// Names of the phases that have automatic procedures.
+const (
+	Reinforcements          = "Reinforcement Phase"             // scheduled reinforcements arrive
+	InitiativeDetermination = "Initiative Determination Phase"  // the sides roll for the initiative
+	StoresExpenditure       = "Stores Expenditure Phase"        // units use stores and water
+	Evaporation             = "Evaporation Phase"               // supply is lost to evaporation
+	FirstBreakdown          = "First Player Breakdown Segment"  // the first player's vehicles break down
+	SecondBreakdown         = "Second Player Breakdown Segment" // the second player's vehicles break down
+	Record                  = "Game-Turn Record Phase"          // railroad construction and rail capacity
+)
+
+// these phases happen once at the start of each game-turn.
+var turnPhases = []*PHASE{
+	{Name: "Weather Determination Phase", Player: Automatic},
+	{Name: "Naval Convoy Schedule Phase", Player: Both, Orders: []string{"convoy"}},
+	{Name: Reinforcements, Player: Automatic},
+	{Name: "Organization Phase", Player: Both, Orders: []string{"organize", "build"}},
+}
+
+// these phases happen in each operations stage.
+var stagePhases = []*PHASE{
+	{Name: "Naval Convoy Arrival Phase", Player: Both, Orders: []string{"unload"}},
+	{Name: InitiativeDetermination, Player: Automatic},
+	{Name: "Air Mission Phase", Player: Both, Orders: []string{"fly"}},
+	{Name: "First Player Reserve Designation Phase", Player: First, Orders: []string{"reserve"}},
+	{Name: "First Player Movement Segment", Player: First, Orders: []string{"move", "rail", "load", "unload", "haul", "build"}},
+	{Name: FirstBreakdown, Player: Automatic},
+	{Name: "Second Player Reaction Segment", Player: Second, Orders: []string{"move"}},
+	{Name: "First Player Barrage Segment", Player: First, Orders: []string{"barrage"}},
+	{Name: "Second Player Retreat Before Assault Segment", Player: Second, Orders: []string{"retreat"}},
+	{Name: "First Player Close Assault Segment", Player: First, Orders: []string{"attack"}},
+	{Name: "Second Player Reserve Designation Phase", Player: Second, Orders: []string{"reserve"}},
+	{Name: "Second Player Movement Segment", Player: Second, Orders: []string{"move", "rail", "load", "unload", "haul", "build"}},
+	{Name: SecondBreakdown, Player: Automatic},
+	{Name: "First Player Reaction Segment", Player: First, Orders: []string{"move"}},
+	{Name: "Second Player Barrage Segment", Player: Second, Orders: []string{"barrage"}},
+	{Name: "First Player Retreat Before Assault Segment", Player: First, Orders: []string{"retreat"}},
+	{Name: "Second Player Close Assault Segment", Player: Second, Orders: []string{"attack"}},
+	{Name: StoresExpenditure, Player: Automatic},
+	{Name: Evaporation, Player: Automatic},
+	{Name: "Repair Phase", Player: Both, Orders: []string{"repair"}},
+}
+
+// these phases happen once at the end of each game-turn.
+var endPhases = []*PHASE{
+	{Name: Record, Player: Automatic},
+}
+
+// STEP is a phase in a particular operations stage.
+// Stage is zero for phases that happen once per game-turn.
+type STEP struct {
+	Stage int
+	Phase *PHASE
+}
+
+// Sequence is the full sequence of play for a single game-turn.
+var Sequence = func() (steps []STEP) {
+	for _, p := range turnPhases {
+		steps = append(steps, STEP{Phase: p})
+	}
+	for stage := 1; stage <= Stages; stage++ {
+		for _, p := range stagePhases {
+			steps = append(steps, STEP{Stage: stage, Phase: p})
+		}
+	}
+	for _, p := range endPhases {
+		steps = append(steps, STEP{Phase: p})
+	}
+	return steps
+}()
+
+// String implements fmt.Stringer
+func (s STEP) String() string {
+	if s.Stage == 0 {
+		return s.Phase.Name
+	}
+	return fmt.Sprintf("Operations Stage %d, %s", s.Stage, s.Phase.Name)
+}
+
+// index returns the position of the game's current phase in the sequence.
+// A game that has not started is at the first phase.
+func index(g *model.GAME) (int, error) {
+	if g.Phase == "" {
+		return 0, nil
+	}
+	for n, step := range Sequence {
+		if step.Stage == g.Stage && step.Phase.Name == g.Phase {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("stage %d: unknown phase %q", g.Stage, g.Phase)
+}
+
+// Current returns the game's current step in the sequence of play.
+func Current(g *model.GAME) (STEP, error) {
+	n, err := index(g)
+	if err != nil {
+		return STEP{}, err
+	}
+	return Sequence[n], nil
+}
+
+// Phasing returns the side that acts in the current phase.
+// It returns NoSide when both players act or when the phase is automatic.
+func Phasing(g *model.GAME) (model.SIDE, error) {
+	step, err := Current(g)
+	if err != nil {
+		return model.NoSide, err
+	}
+	first := Initiative(g)
+	switch step.Phase.Player {
+	case First:
+		return first, nil
+	case Second:
+		return first.Enemy(), nil
+	}
+	return model.NoSide, nil
+}
+
+// Initiative returns the side with the initiative. The Commonwealth has it
+// until it is first determined.
+func Initiative(g *model.GAME) model.SIDE {
+	if g.Initiative == model.NoSide {
+		return model.Commonwealth
+	}
+	return g.Initiative
+}
+
+// Legal returns an error if the side may not give an order with the verb
+// in the current phase.
+func Legal(g *model.GAME, side model.SIDE, verb string) error {
+	step, err := Current(g)
+	if err != nil {
+		return err
+	}
+	if step.Phase.Player == Automatic {
+		return fmt.Errorf("no orders are allowed in the %s", step.Phase.Name)
+	}
+	if phasing, _ := Phasing(g); phasing != model.NoSide && phasing != side {
+		return fmt.Errorf("%s is not the phasing player in the %s", side, step.Phase.Name)
+	}
+	for _, o := range step.Phase.Orders {
+		if o == verb {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q orders are not allowed in the %s", verb, step.Phase.Name)
+}
+
+// Advance moves the game to the next phase, rolling over to the next
+// game-turn after the last phase. It returns an error instead of
+// advancing past the last game-turn of the scenario.
+func Advance(g *model.GAME) (STEP, error) {
+	n, err := index(g)
+	if err != nil {
+		return STEP{}, err
+	}
+	turn := g.Turn
+	if n++; n == len(Sequence) {
+		if g.LastTurn != 0 && g.Turn >= g.LastTurn {
+			return STEP{}, fmt.Errorf("game is over after game-turn %d", g.LastTurn)
+		}
+		n, turn = 0, turn+1
+	}
+
+	step := Sequence[n]
+	g.Turn, g.Stage, g.Phase = turn, step.Stage, step.Phase.Name
+
+	if step.Phase.Name == Reinforcements {
+		arrive(g)
+	} else if step.Stage != 0 && step.Phase == stagePhases[0] {
+		refit(g)
+	}
+
+	return step, nil
+}
+
+// arrive places the reinforcements that are scheduled for this game-turn.
+func arrive(g *model.GAME) {
+	var pending []*model.REINFORCEMENT
+	for _, r := range g.Reinforcements {
+		if r.Turn > g.Turn {
+			pending = append(pending, r)
+		} else if unit := g.Units.ById(r.Unit); unit != nil {
+			unit.Hex = r.Hex
+		}
+	}
+	g.Reinforcements = pending
+}
+
+// refit clears the capability points spent and the reserve designations
+// at the start of each operations stage.
+func refit(g *model.GAME) {
+	for _, u := range g.Units {
+		u.CP, u.Reserve = 0, false
+	}
+}
@@ -0,0 +1,186 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package visibility decides what each side can see of the other.
+//
+// A side sees the hexes near its own units, farther around its
+// reconnaissance units, and around the hexes it flew air reconnaissance
+// over this game-turn. Everything else about the enemy is hidden, and
+// Filter removes it from the game before reports and maps are made.
+package visibility
+
+import (
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+)
+
+// RULES are the ranges, in hexes, that a side can see.
+type RULES struct {
+	Unit  int // around any unit
+	Recon int // around a reconnaissance unit
+	Air   int // around the target of an air reconnaissance mission
+}
+
+// Default is the standard visibility. Units see adjacent hexes and
+// reconnaissance units see two hexes out.
+var Default = RULES{Unit: 1, Recon: 2, Air: 1}
+
+// VIEW is the set of hexes that a side can see.
+type VIEW struct {
+	Side  model.SIDE
+	hexes map[*model.HEX]bool
+}
+
+// Compute returns the hexes that the side can see.
+func Compute(g *model.GAME, rules RULES, side model.SIDE) *VIEW {
+	v := &VIEW{Side: side, hexes: make(map[*model.HEX]bool)}
+	for _, u := range g.Units {
+		if u.Side() != side {
+			continue
+		} else if hex := g.Map.Lookup(u.Hex); hex != nil {
+			if u.Type == model.Recon {
+				v.spot(g.Map, hex, rules.Recon)
+			} else {
+				v.spot(g.Map, hex, rules.Unit)
+			}
+		}
+	}
+	for _, r := range g.Recon {
+		if r.Side != side || r.Turn != g.Turn {
+			continue
+		} else if hex := g.Map.Lookup(r.Hex); hex != nil {
+			v.spot(g.Map, hex, rules.Air)
+		}
+	}
+	return v
+}
+
+// spot marks every hex within the range of the hex as seen.
+func (v *VIEW) spot(board *model.MAP, hex *model.HEX, distance int) {
+	v.hexes[hex] = true
+	ring := []*model.HEX{hex}
+	for ; distance > 0; distance-- {
+		var next []*model.HEX
+		for _, h := range ring {
+			for _, neighbor := range board.Neighbors(h) {
+				if neighbor != nil && !v.hexes[neighbor] {
+					v.hexes[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		ring = next
+	}
+}
+
+// Sees returns true if the side can see into the hex.
+func (v *VIEW) Sees(hex *model.HEX) bool {
+	return v.hexes[hex]
+}
+
+// Hexes returns the hexes the side can see, sorted.
+func (v *VIEW) Hexes() (hexes model.HEXES) {
+	for hex := range v.hexes {
+		hexes = append(hexes, hex)
+	}
+	sort.Sort(hexes)
+	return hexes
+}
+
+// Filter returns a copy of the game holding only what the side may know.
+// Enemy units and dumps that can not be seen, enemy reinforcements,
+// enemy supply sources and railheads, enemy reconnaissance, enemy die
+// rolls, enemy railroad construction, enemy ledger entries, and the
+// dice seed are removed; the hash of the seed is kept. The stock in
+// enemy units and dumps is hidden even when they can be seen. The board
+// is shared with the original game; the units and dumps are copies.
+func (v *VIEW) Filter(g *model.GAME) *model.GAME {
+	f := *g
+	f.Seed = ""
+
+	f.Units = nil
+	for _, u := range g.Units {
+		if u.Side() == v.Side {
+			unit := *u
+			f.Units = append(f.Units, &unit)
+		} else if u.Hex != "" && v.Sees(g.Map.Lookup(u.Hex)) {
+			unit := *u
+			unit.Stock, unit.Tank = nil, nil
+			f.Units = append(f.Units, &unit)
+		}
+	}
+
+	f.Reinforcements = nil
+	for _, r := range g.Reinforcements {
+		if u := g.Units.ById(r.Unit); u != nil && u.Side() == v.Side {
+			reinforcement := *r
+			f.Reinforcements = append(f.Reinforcements, &reinforcement)
+		}
+	}
+
+	f.Players = nil
+	for _, p := range g.Players {
+		player := *p
+		if p.Side != v.Side {
+			player.Sources, player.Railhead, player.RailTons = nil, "", 0
+		}
+		f.Players = append(f.Players, &player)
+	}
+
+	f.Recon = nil
+	for _, r := range g.Recon {
+		if r.Side == v.Side {
+			recon := *r
+			f.Recon = append(f.Recon, &recon)
+		}
+	}
+
+	f.Dumps = nil
+	for _, d := range g.Dumps {
+		if d.Side == v.Side {
+			dump := *d
+			f.Dumps = append(f.Dumps, &dump)
+		} else if v.Sees(g.Map.Lookup(d.Hex)) {
+			f.Dumps = append(f.Dumps, &model.DUMP{Hex: d.Hex, Side: d.Side})
+		}
+	}
+
+	f.Ledger = nil
+	for _, e := range g.Ledger {
+		if e.Side == v.Side {
+			f.Ledger = append(f.Ledger, e)
+		}
+	}
+
+	f.Rolls = nil
+	for _, r := range g.Rolls {
+		if r.Side == v.Side || r.Side == model.NoSide {
+			f.Rolls = append(f.Rolls, r)
+		}
+	}
+
+	f.Rail = nil
+	for _, w := range g.Rail {
+		if w.Side == v.Side {
+			work := *w
+			f.Rail = append(f.Rail, &work)
+		}
+	}
+
+	return &f
+}
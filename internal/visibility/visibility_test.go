@@ -0,0 +1,145 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+package visibility
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"sort"
+	"testing"
+)
+
+// game returns a two-sided game on a single row of clear hexes labelled
+// "C1" to "C9". The axis has a unit in C1 and can see C1 and C2. The
+// commonwealth has a unit in C2, which the axis can see, and one in C8,
+// which it can not. Each side has a dump, a railhead, rail construction,
+// die rolls and ledger entries.
+func game() *model.GAME {
+	board := &model.MAP{Hexes: make(map[string]*model.HEX)}
+	for col := 1; col <= 9; col++ {
+		hex := &model.HEX{Row: 1, Column: col, Label: fmt.Sprintf("C%d", col), Terrain: model.Clear}
+		board.Hexes[fmt.Sprintf("%02d%03d", 1, col)] = hex
+		board.Sorted = append(board.Sorted, hex)
+	}
+	sort.Sort(board.Sorted)
+	return &model.GAME{
+		Map:  board,
+		Turn: 1,
+		Players: []*model.PLAYER{
+			{Side: model.Axis, Sources: []string{"C1"}, Railhead: "C1", RailTons: 10},
+			{Side: model.Commonwealth, Sources: []string{"C9"}, Railhead: "C9", RailTons: 20},
+		},
+		Units: model.UNITS{
+			{Id: "21pz/104", Nationality: model.German, Type: model.Infantry, Hex: "C1", Stock: model.STOCK{model.Fuel: 5}},
+			{Id: "7armd/4armdbde", Nationality: model.British, Type: model.Armor, Hex: "C2", Stock: model.STOCK{model.Fuel: 3}},
+			{Id: "7armd/supply", Nationality: model.British, Type: model.Truck, Hex: "C8", Stock: model.STOCK{model.Fuel: 40}},
+			{Id: "4ind", Nationality: model.Indian, Type: model.Infantry},
+			{Id: "15pz", Nationality: model.German, Type: model.Armor},
+		},
+		Reinforcements: []*model.REINFORCEMENT{{Turn: 3, Unit: "4ind"}, {Turn: 2, Unit: "15pz"}},
+		Recon:          []*model.RECON{{Side: model.Axis, Turn: 1, Hex: "C5"}, {Side: model.Commonwealth, Turn: 1, Hex: "C1"}},
+		Seed:           "abcd",
+		SeedHash:       "88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589",
+		Rolls: []*model.ROLL{
+			{Turn: 1, Stage: 1, Context: "initiative:axis", Sides: 6, Dice: []int{4}},
+			{Turn: 1, Stage: 1, Side: model.Axis, Context: "axis:3", Purpose: "close assault on C2", Sides: 6, Dice: []int{2, 5}},
+			{Turn: 1, Stage: 1, Side: model.Commonwealth, Context: "breakdown:7armd/supply", Purpose: "breakdown of 7armd/supply", Sides: 6, Dice: []int{6, 6}},
+		},
+		Dumps: []*model.DUMP{
+			{Hex: "C1", Side: model.Axis, Stock: model.STOCK{model.Ammo: 30}},
+			{Hex: "C2", Side: model.Commonwealth, Stock: model.STOCK{model.Ammo: 50}},
+			{Hex: "C8", Side: model.Commonwealth, Stock: model.STOCK{model.Fuel: 90}},
+		},
+		Ledger: []*model.ENTRY{
+			{Turn: 1, Side: model.Axis, Kind: model.EntryLoss, Supply: model.Fuel, Amount: 1, From: "21pz/104"},
+			{Turn: 1, Side: model.Commonwealth, Kind: model.EntryLoss, Supply: model.Fuel, Amount: 2, From: "7armd/supply"},
+		},
+		Rail: []*model.RAILWORK{
+			{Side: model.Axis, Hex: "C1", Direction: model.DirE, Turns: 1},
+			{Side: model.Commonwealth, Hex: "C8", Direction: model.DirE, Turns: 2},
+		},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	g := game()
+	f := Compute(g, Default, model.Axis).Filter(g)
+
+	if f.Seed != "" {
+		t.Errorf("seed: want none, got %q", f.Seed)
+	} else if f.SeedHash != g.SeedHash {
+		t.Errorf("seed hash: want %q, got %q", g.SeedHash, f.SeedHash)
+	}
+
+	var units []string
+	for _, u := range f.Units {
+		units = append(units, u.Id)
+		if u.Side() != model.Axis && len(u.Stock) != 0 {
+			t.Errorf("unit %q: enemy stock is not hidden", u.Id)
+		}
+	}
+	if got, want := fmt.Sprint(units), "[21pz/104 7armd/4armdbde 15pz]"; got != want {
+		t.Errorf("units: want %s, got %s", want, got)
+	}
+	if len(f.Reinforcements) != 1 || f.Reinforcements[0].Unit != "15pz" {
+		t.Errorf("reinforcements: want only 15pz, got %v", f.Reinforcements)
+	}
+
+	for _, p := range f.Players {
+		if p.Side == model.Axis && (p.Railhead != "C1" || p.RailTons != 10 || len(p.Sources) != 1) {
+			t.Errorf("axis player: want own railhead, tons and sources, got %+v", p)
+		} else if p.Side == model.Commonwealth && (p.Railhead != "" || p.RailTons != 0 || len(p.Sources) != 0) {
+			t.Errorf("commonwealth player: want nothing, got %+v", p)
+		}
+	}
+
+	if len(f.Recon) != 1 || f.Recon[0].Side != model.Axis {
+		t.Errorf("recon: want only axis, got %v", f.Recon)
+	}
+	for _, r := range f.Rolls {
+		if r.Side == model.Commonwealth {
+			t.Errorf("rolls: enemy roll %s was not removed", r)
+		}
+	}
+	if len(f.Rolls) != 2 {
+		t.Errorf("rolls: want the public and the axis roll, got %d", len(f.Rolls))
+	}
+	if len(f.Rail) != 1 || f.Rail[0].Side != model.Axis {
+		t.Errorf("rail: want only axis construction, got %v", f.Rail)
+	}
+	if len(f.Ledger) != 1 || f.Ledger[0].Side != model.Axis {
+		t.Errorf("ledger: want only axis entries, got %v", f.Ledger)
+	}
+
+	var dumps []string
+	for _, d := range f.Dumps {
+		dumps = append(dumps, d.Hex)
+		if d.Side != model.Axis && len(d.Stock) != 0 {
+			t.Errorf("dump %s: enemy stock is not hidden", d.Hex)
+		}
+	}
+	if got, want := fmt.Sprint(dumps), "[C1 C2]"; got != want {
+		t.Errorf("dumps: want %s, got %s", want, got)
+	}
+
+	// the original game is not changed
+	if g.Seed == "" || len(g.Units) != 5 || g.Players[1].Railhead != "C9" || len(g.Rolls) != 3 || len(g.Rail) != 2 || g.Units[1].Stock[model.Fuel] != 3 {
+		t.Errorf("filter changed the original game")
+	}
+}
@@ -0,0 +1,137 @@
+/*******************************************************************************
+ * TCFNA - Game Engine for SPI's Campaign for North Africa
+ * Copyright (C) 2022. Michael D Henderson
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ ******************************************************************************/
+
+// Package zoc computes zones of control.
+//
+// A combat unit exerts a zone of control into its own hex and each
+// adjacent hex, except across a river, the Nile or a sea hexside, up an
+// escarpment, or into a sea hex. Trucks and headquarters do not exert
+// a zone of control, and neither do units that have been eliminated.
+package zoc
+
+import (
+	"fmt"
+	"github.com/mdhender/tcfna/internal/model"
+	"github.com/mdhender/tcfna/internal/route"
+	"sort"
+)
+
+// RULES are the extra capability points a unit spends to move into
+// and out of an enemy zone of control. A unit moving from one enemy
+// zone of control into another pays both.
+type RULES struct {
+	Enter float64
+	Leave float64
+}
+
+// Default is the standard cost for enemy zones of control.
+var Default = RULES{Enter: 2, Leave: 1}
+
+// ZOC is the set of hexes in one side's zone of control.
+type ZOC struct {
+	Side  model.SIDE
+	hexes map[*model.HEX]int // number of units exerting control on the hex
+}
+
+// Exerts returns true if the unit exerts a zone of control.
+func Exerts(u *model.UNIT) bool {
+	if u.Hex == "" || u.TOE <= 0 {
+		return false
+	}
+	return u.Type != model.Truck && u.Type != model.Headquarters
+}
+
+// Compute returns the zone of control of the side's units.
+func Compute(g *model.GAME, side model.SIDE) *ZOC {
+	z := &ZOC{Side: side, hexes: make(map[*model.HEX]int)}
+	for _, u := range g.Units {
+		if u.Side() != side || !Exerts(u) {
+			continue
+		}
+		hex := g.Map.Lookup(u.Hex)
+		if hex == nil {
+			continue
+		}
+		z.hexes[hex]++
+		for _, d := range model.Directions {
+			if to := g.Map.Neighbor(hex, d); to != nil && extends(g.Map.Hexside(hex, d)) && !to.Terrain.Attributes().Sea {
+				z.hexes[to]++
+			}
+		}
+	}
+	return z
+}
+
+// extends returns true if a zone of control extends across the hexside.
+// The elevation is as seen from the hex of the unit exerting control.
+func extends(side model.HEXSIDE) bool {
+	switch side.Water {
+	case model.River, model.Nile, model.SeaHexside:
+		return false
+	}
+	return side.Elevation != model.UpEscarpment
+}
+
+// In returns true if the hex is in the zone of control.
+func (z *ZOC) In(hex *model.HEX) bool {
+	return z.hexes[hex] != 0
+}
+
+// Hexes returns the hexes in the zone of control, sorted.
+func (z *ZOC) Hexes() (hexes model.HEXES) {
+	for hex := range z.hexes {
+		hexes = append(hexes, hex)
+	}
+	sort.Sort(hexes)
+	return hexes
+}
+
+// Extra returns the capability points, beyond the movement cost, that
+// the side's enemy spends to move from one hex into the other.
+func (z *ZOC) Extra(rules RULES, from, to *model.HEX) (extra float64) {
+	if z.In(from) {
+		extra += rules.Leave
+	}
+	if z.In(to) {
+		extra += rules.Enter
+	}
+	return extra
+}
+
+// Cost wraps a path search cost function so that it charges the extra
+// cost of moving into and out of the zone of control. The extra cost is
+// never negative, so the search's minimum cost is still valid.
+func (z *ZOC) Cost(board *model.MAP, rules RULES, cost route.COST) route.COST {
+	return func(from *model.HEX, d model.DIRECTION) (float64, bool) {
+		c, ok := cost(from, d)
+		if !ok {
+			return 0, false
+		}
+		return c + z.Extra(rules, from, board.Neighbor(from, d)), true
+	}
+}
+
+// Overlay implements memory.OVERLAY. It shades hexes in the zone of
+// control and notes how many units exert control on each.
+func (z *ZOC) Overlay(hex *model.HEX) (fill, note string) {
+	n := z.hexes[hex]
+	if n == 0 {
+		return "", ""
+	}
+	return model.COLOR{Hue: 30, Saturation: 0.80, Lightness: 0.70}.String(), fmt.Sprintf("zoc %d", n)
+}